@@ -0,0 +1,45 @@
+package httpclient
+
+import "net/http"
+
+// Authentication scheme applied by [Send] to every attempt,
+// so credentials can change over time or depend on the request itself
+// (such as signatures including a timestamp).
+// Implementations must be safe for concurrent use by clones.
+type Authenticator interface {
+	// Decorate the outgoing request of a single attempt,
+	// typically by setting an Authorization header.
+	// The given request is a copy with its own Header,
+	// but any body must be replaced rather than consumed.
+	Authenticate(req *http.Request) error
+	// Inspect a 401 Unauthorized response (including its Request),
+	// and report whether the request should be repeated immediately
+	// with renewed credentials. This is attempted at most once.
+	Challenge(res *http.Response) bool
+}
+
+// Optional extension of an [Authenticator] sending requests of its own,
+// such as to obtain tokens. [Send] calls AuthenticateWith instead of
+// Authenticate, given the client of the attempt so its proxy,
+// TLS configuration and timeout also apply to those requests.
+type ClientAuthenticator interface {
+	Authenticator
+	AuthenticateWith(client *http.Client, req *http.Request) error
+}
+
+// Function to decorate each attempt as a simple [Authenticator]
+// without reacting to challenges.
+//
+//	api.SetAuth(httpclient.AuthFunc(func(req *http.Request) error {
+//		req.Header.Set("X-Api-Key", keys.Current())
+//		return nil
+//	}))
+type AuthFunc func(req *http.Request) error
+
+func (f AuthFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+func (f AuthFunc) Challenge(res *http.Response) bool {
+	return false
+}
@@ -0,0 +1,592 @@
+package httpclient
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Storage backend of a [Cache], keeping serialised entries by key.
+// Implementations must be safe for concurrent use.
+type CacheStore interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
+}
+
+// HTTP cache (RFC 9111) consulted by [Send] for GET requests.
+//
+// Fresh responses are served without contacting the server,
+// while stale ones are revalidated by If-None-Match or If-Modified-Since
+// and served again if the server replies 304 Not Modified.
+// Storage and freshness are determined by Cache-Control
+// (max-age, s-maxage, no-cache, no-store, private),
+// Expires and Last-Modified heuristics,
+// and variants are distinguished by Vary.
+// Stale responses may be served as [Stale] instead, according to
+// Cache-Control extensions of RFC 5861: stale-while-revalidate
+// responses are given immediately while being revalidated in the background,
+// and stale-if-error responses replace any failure or server error,
+// unless also marked no-cache or must-revalidate.
+// Requests with their own preconditions (such as [SetIfNoneMatch])
+// are answered by 304 Not Modified if a fresh entry satisfies them,
+// or otherwise passed on to the server.
+type Cache struct {
+	Store CacheStore
+	// Behave as a shared instead of a private cache,
+	// preferring s-maxage and never storing private responses.
+	Shared bool
+	// Largest body to keep, or DefaultCacheEntrySize if 0.
+	// Responses without a declared Content-Length are never stored,
+	// so large downloads are passed through without buffering.
+	MaxEntrySize int64
+
+	mu         sync.Mutex
+	refreshing map[string]bool // background revalidations by key
+}
+
+// Default [Cache.MaxEntrySize] of 10 MiB.
+const DefaultCacheEntrySize = 10 << 20
+
+// Prepare a private [Cache] in the given storage.
+func NewCache(store CacheStore) *Cache {
+	return &Cache{Store: store}
+}
+
+// Stored response with the request details needed to reuse it.
+type cacheEntry struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+	Vary       http.Header // request headers selected by Vary
+	Requested  time.Time   // request_time of RFC 9111 section 4.2.3
+	Received   time.Time   // response_time
+}
+
+// Status codes cacheable by default (RFC 9110 section 15.1),
+// except for partial content which is never combined.
+var cacheableStatus = map[int]bool{
+	200: true, 203: true, 204: true, 300: true, 301: true, 308: true,
+	404: true, 405: true, 410: true, 414: true, 501: true,
+}
+
+// Cache-Control directives in lowercase, with any unquoted value.
+type cacheControl map[string]string
+
+func parseCacheControl(h http.Header) cacheControl {
+	cc := make(cacheControl)
+	for _, line := range h.Values("Cache-Control") {
+		for _, directive := range strings.Split(line, ",") {
+			k, v, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if k = strings.ToLower(k); k != "" {
+				cc[k] = strings.Trim(v, `"`)
+			}
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(directive string) bool {
+	_, found := cc[directive]
+	return found
+}
+
+// Number of seconds of a directive, or false if missing or invalid.
+func (cc cacheControl) seconds(directive string) (time.Duration, bool) {
+	v, found := cc[directive]
+	if !found {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Second, true
+}
+
+func (c *Cache) key(r *Request) string {
+	return r.Request.URL.String()
+}
+
+func (c *Cache) load(key string) *cacheEntry {
+	data, found := c.Store.Get(key)
+	if !found {
+		return nil
+	}
+	e := new(cacheEntry)
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(e); err != nil {
+		c.Store.Delete(key) // unreadable
+		return nil
+	}
+	return e
+}
+
+func (c *Cache) save(key string, e *cacheEntry) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(e); err == nil {
+		c.Store.Set(key, buf.Bytes())
+	}
+}
+
+// Send a request through the cache, see [Request.Send].
+func (c *Cache) send(r *Request) (err error) {
+	if r.Error != nil {
+		return r.Error
+	}
+	method := r.Request.Method
+	if method != "" && method != "GET" {
+		err = r.Resend()
+		if err == nil && method != "HEAD" && r.StatusCode < 400 {
+			// invalidated by unsafe methods (RFC 9111 section 4.4)
+			c.Store.Delete(c.key(r))
+		}
+		return
+	}
+
+	h := r.Request.Header
+	reqcc := parseCacheControl(h)
+	if reqcc.has("no-store") || h.Get("Range") != "" {
+		return r.Resend()
+	}
+	conditional := h.Get("If-None-Match") != "" || h.Get("If-Modified-Since") != ""
+	key := c.key(r)
+	e := c.load(key)
+	if e != nil && !e.matches(h) {
+		e = nil // different variant
+	}
+	if e == nil {
+		return c.revalidate(r, key, nil)
+	}
+
+	now := time.Now()
+	if !reqcc.has("no-cache") && e.fresh(c.Shared, reqcc, now) {
+		r.Response = e.response(r.Request)
+		if conditional && e.notModified(h) {
+			// answer the request conditions on behalf of the server
+			r.Response.StatusCode = http.StatusNotModified
+			r.Response.Status = "304 Not Modified"
+			r.Response.Body = http.NoBody
+			r.Response.ContentLength = 0
+		}
+		r.CacheHit = true
+		return
+	}
+	if conditional {
+		return c.revalidate(r, key, nil) // preconditions left to the server
+	}
+	if !reqcc.has("no-cache") && e.staleWithin("stale-while-revalidate", c.Shared, now) {
+		e.serve(r)
+		c.refresh(r, key, e)
+		return
+	}
+
+	err = c.revalidate(r, key, e)
+	failed := err != nil || r.StatusCode >= 500
+	if failed && e.staleWithin("stale-if-error", c.Shared, time.Now()) {
+		if r.Response != nil {
+			r.Response.Body.Close()
+		}
+		e.serve(r)
+		return nil
+	}
+	return
+}
+
+// Send a request to the server, conditional on any stored entry,
+// and store or update the response if allowed.
+func (c *Cache) revalidate(r *Request, key string, e *cacheEntry) (err error) {
+	h := r.Request.Header
+	// preconditions of the caller make any response incomplete
+	conditional := h.Get("If-None-Match") != "" || h.Get("If-Modified-Since") != ""
+	validated := false
+	if e != nil && !conditional {
+		if etag := e.Header.Get("ETag"); etag != "" {
+			h.Set("If-None-Match", etag)
+			defer h.Del("If-None-Match")
+			validated = true
+		}
+		if modified := e.Header.Get("Last-Modified"); modified != "" {
+			h.Set("If-Modified-Since", modified)
+			defer h.Del("If-Modified-Since")
+			validated = true
+		}
+	}
+
+	requested := time.Now()
+	if err = r.Resend(); err != nil {
+		return
+	}
+	received := time.Now()
+
+	if validated && r.StatusCode == http.StatusNotModified {
+		r.Response.Body.Close()
+		e.update(r.Response.Header, requested, received)
+		c.save(key, e)
+		r.Response = e.response(r.Request)
+		r.CacheHit = true
+		return
+	}
+	if !conditional && c.storable(r) {
+		c.store(r, key, requested, received)
+	}
+	return
+}
+
+// Revalidate a stale entry in the background,
+// at most once at a time for each key.
+func (c *Cache) refresh(r *Request, key string, e *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.refreshing == nil {
+		c.refreshing = make(map[string]bool)
+	}
+	if c.refreshing[key] {
+		return
+	}
+	c.refreshing[key] = true
+
+	d := r.Clone()
+	ctx := context.WithoutCancel(d.Request.Context()) // outlive the caller
+	d.Request = d.Request.WithContext(ctx)
+	d.OnProgress = nil
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.refreshing, key)
+			c.mu.Unlock()
+		}()
+		d.Attempt = 0
+		if err := c.revalidate(d, key, e); err == nil {
+			d.Response.Body.Close()
+		}
+	}()
+}
+
+// Determine if a received response may be stored (RFC 9111 section 3).
+func (c *Cache) storable(r *Request) bool {
+	cc := parseCacheControl(r.Response.Header)
+	switch {
+	case r.StatusCode == http.StatusNotModified:
+		return false // only updates existing entries
+	case cc.has("no-store"):
+		return false
+	case r.Response.Header.Get("Vary") == "*":
+		return false
+	case c.Shared && cc.has("private"):
+		return false
+	case c.Shared && r.Request.Header.Get("Authorization") != "" &&
+		!cc.has("public") && !cc.has("s-maxage") && !cc.has("must-revalidate"):
+		return false
+	}
+	if cacheableStatus[r.StatusCode] || cc.has("public") {
+		return true
+	}
+	_, explicit := cc.seconds("max-age")
+	if c.Shared && cc.has("s-maxage") {
+		explicit = true
+	}
+	return explicit || r.Response.Header.Get("Expires") != ""
+}
+
+// Read the entire response body to keep a copy,
+// replacing it by an equivalent reader.
+func (c *Cache) store(r *Request, key string, requested, received time.Time) {
+	body := r.Response.Body
+	size := c.MaxEntrySize
+	if size == 0 {
+		size = DefaultCacheEntrySize
+	}
+	if r.Response.ContentLength < 0 || r.Response.ContentLength > size {
+		return // left untouched
+	}
+	limit := r.MaxBodySize
+	if limit > 0 && r.Response.ContentLength > limit {
+		return // refused anyway
+	}
+	var data []byte
+	var err error
+	if limit > 0 {
+		data, err = io.ReadAll(io.LimitReader(body, limit+1))
+	} else {
+		data, err = io.ReadAll(body)
+	}
+	if err != nil || limit > 0 && int64(len(data)) > limit {
+		// leave any failure to be reported by the actual reader
+		r.Response.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), body), body}
+		return
+	}
+	body.Close()
+	r.Response.Body = io.NopCloser(bytes.NewReader(data))
+
+	e := &cacheEntry{
+		StatusCode: r.StatusCode,
+		Status:     r.Response.Status,
+		Header:     r.Response.Header.Clone(),
+		Body:       data,
+		Vary:       make(http.Header),
+		Requested:  requested,
+		Received:   received,
+	}
+	e.Header.Del("Content-Length") // recalculated
+	for _, line := range r.Response.Header.Values("Vary") {
+		for _, name := range strings.Split(line, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				e.Vary[http.CanonicalHeaderKey(name)] = r.Request.Header.Values(name)
+			}
+		}
+	}
+	c.save(key, e)
+}
+
+// Compare request headers nominated by Vary.
+func (e *cacheEntry) matches(h http.Header) bool {
+	for name, values := range e.Vary {
+		if strings.Join(h.Values(name), ",") != strings.Join(values, ",") {
+			return false
+		}
+	}
+	return true
+}
+
+// Current age of a stored response (RFC 9111 section 4.2.3).
+func (e *cacheEntry) age(now time.Time) time.Duration {
+	var apparent, corrected time.Duration
+	if date, err := http.ParseTime(e.Header.Get("Date")); err == nil {
+		apparent = e.Received.Sub(date)
+	}
+	if n, err := strconv.ParseInt(e.Header.Get("Age"), 10, 64); err == nil && n > 0 {
+		corrected = time.Duration(n) * time.Second
+	}
+	corrected += e.Received.Sub(e.Requested)
+	if apparent > corrected {
+		corrected = apparent
+	}
+	return corrected + now.Sub(e.Received)
+}
+
+// Freshness lifetime of a stored response (RFC 9111 section 4.2.1).
+func (e *cacheEntry) lifetime(shared bool) time.Duration {
+	cc := parseCacheControl(e.Header)
+	if shared {
+		if v, found := cc.seconds("s-maxage"); found {
+			return v
+		}
+	}
+	if v, found := cc.seconds("max-age"); found {
+		return v
+	}
+	date, err := http.ParseTime(e.Header.Get("Date"))
+	if err != nil {
+		date = e.Received
+	}
+	if v := e.Header.Get("Expires"); v != "" {
+		expires, err := http.ParseTime(v)
+		if err != nil {
+			return 0 // invalid dates are already expired
+		}
+		return expires.Sub(date)
+	}
+	if modified, err := http.ParseTime(e.Header.Get("Last-Modified")); err == nil {
+		return date.Sub(modified) / 10 // heuristic (section 4.2.2)
+	}
+	return 0
+}
+
+// Whether a stale entry may still be served
+// within the number of seconds of a Cache-Control extension (RFC 5861),
+// unless the response requires revalidation (RFC 9111 section 5.2.2).
+func (e *cacheEntry) staleWithin(directive string, shared bool, now time.Time) bool {
+	cc := parseCacheControl(e.Header)
+	if cc.has("no-cache") || cc.has("must-revalidate") || shared && cc.has("proxy-revalidate") {
+		return false
+	}
+	window, found := cc.seconds(directive)
+	return found && e.age(now) < e.lifetime(shared)+window
+}
+
+// Provide a stale entry as response.
+func (e *cacheEntry) serve(r *Request) {
+	r.Response = e.response(r.Request)
+	r.CacheHit = true
+	r.Stale = true
+}
+
+func (e *cacheEntry) fresh(shared bool, reqcc cacheControl, now time.Time) bool {
+	cc := parseCacheControl(e.Header)
+	if cc.has("no-cache") {
+		return false // always revalidated
+	}
+	age := e.age(now)
+	if maxAge, found := reqcc.seconds("max-age"); found && age > maxAge {
+		return false
+	}
+	return e.lifetime(shared) > age
+}
+
+// Evaluate request preconditions of If-None-Match or If-Modified-Since
+// (RFC 9110 section 13.2.2) to determine if the entry is unchanged.
+func (e *cacheEntry) notModified(h http.Header) bool {
+	if match := h.Get("If-None-Match"); match != "" {
+		etag := strings.TrimPrefix(e.Header.Get("ETag"), "W/")
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || etag != "" && tag == etag {
+				return true // weak comparison
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(h.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(e.Header.Get("Last-Modified"))
+	return err == nil && !modified.After(since)
+}
+
+// Apply headers of a 304 Not Modified response (section 4.3.4).
+func (e *cacheEntry) update(h http.Header, requested, received time.Time) {
+	for name, values := range h {
+		switch name {
+		case "Content-Length", "Content-Encoding", "Transfer-Encoding":
+			continue // describing the empty 304 body
+		}
+		e.Header[name] = values
+	}
+	e.Requested, e.Received = requested, received
+}
+
+// Recreate a readable response for the given request.
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        e.Status,
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// In-memory [CacheStore] discarding the least recently used entries
+// beyond a maximum number.
+type MemoryCache struct {
+	Size    int // maximum number of entries, unlimited if 0
+	mu      sync.Mutex
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key   string
+	value []byte
+}
+
+// Prepare an in-memory [CacheStore] of at most size entries.
+func NewMemoryCache(size int) *MemoryCache {
+	return &MemoryCache{
+		Size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (m *MemoryCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, found := m.entries[key]
+	if !found {
+		return nil, false
+	}
+	m.order.MoveToFront(el)
+	return el.Value.(*memoryEntry).value, true
+}
+
+func (m *MemoryCache) Set(key string, value []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, found := m.entries[key]; found {
+		el.Value.(*memoryEntry).value = value
+		m.order.MoveToFront(el)
+		return
+	}
+	m.entries[key] = m.order.PushFront(&memoryEntry{key, value})
+	for m.Size > 0 && m.order.Len() > m.Size {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryEntry).key)
+	}
+}
+
+func (m *MemoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, found := m.entries[key]; found {
+		m.order.Remove(el)
+		delete(m.entries, key)
+	}
+}
+
+// On-disk [CacheStore] keeping each entry in a file of a directory,
+// named by the hash of its key.
+type DiskCache struct {
+	Dir string
+}
+
+// Prepare an on-disk [CacheStore], creating its directory if needed.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &DiskCache{Dir: dir}, nil
+}
+
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.Dir, hex.EncodeToString(sum[:]))
+}
+
+func (d *DiskCache) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(d.path(key))
+	return data, err == nil
+}
+
+func (d *DiskCache) Set(key string, value []byte) {
+	// write completely before replacing atomically
+	f, err := os.CreateTemp(d.Dir, ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(value)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), d.path(key))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+}
+
+func (d *DiskCache) Delete(key string) {
+	os.Remove(d.path(key))
+}
@@ -0,0 +1,277 @@
+package httpclient
+
+import (
+	"testing"
+
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+)
+
+// Server counting requests to cacheable endpoints.
+func cacheServer(hits *atomic.Int32) *httptest.Server {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		etag := `"v1"`
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("ETag", etag)
+		case "/stale":
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", etag)
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/poll":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("ETag", etag)
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/private":
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		case "/nostore":
+			w.Header().Set("Cache-Control", "no-store")
+		case "/swr":
+			w.Header().Set("Cache-Control", "max-age=0, stale-while-revalidate=60")
+		case "/swr/nocache":
+			w.Header().Set("Cache-Control", "no-cache, stale-while-revalidate=60")
+		case "/sie", "/sie/revalidate":
+			if r.URL.Path == "/sie/revalidate" {
+				w.Header().Set("Cache-Control", "max-age=0, must-revalidate, stale-if-error=60")
+			} else {
+				w.Header().Set("Cache-Control", "max-age=0, stale-if-error=60")
+			}
+			if r.Header.Get("X-Fail") != "" {
+				w.WriteHeader(http.StatusBadGateway)
+			}
+		case "/chunked":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.(http.Flusher).Flush() // without Content-Length
+		case "/vary":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "Accept")
+		}
+		fmt.Fprintf(w, "%s #%d", r.URL.Path, n)
+	})
+	return httptest.NewServer(h)
+}
+
+func TestCacheStores(t *testing.T) {
+	disk, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("could not create disk cache: %v", err)
+	}
+	stores := map[string]CacheStore{
+		"memory": NewMemoryCache(10),
+		"disk":   disk,
+	}
+	for name, store := range stores {
+		var hits atomic.Int32
+		s := cacheServer(&hits)
+		defer s.Close()
+		c := NewURL(s.URL)
+		c.SetCache(store)
+
+		expect := map[string][2]string{
+			"fresh":   {"/fresh #1", "/fresh #1"},
+			"stale":   {"/stale #2", "/stale #2"}, // revalidated by #3
+			"nostore": {"/nostore #4", "/nostore #5"},
+			"vary":    {"/vary #6", "/vary #6"},
+		}
+		for _, path := range []string{"fresh", "stale", "nostore", "vary"} {
+			for try := 0; try < 2; try++ {
+				r := c.NewURL(path)
+				body, err := r.Text()
+				if err != nil {
+					t.Fatalf("could not download %s from %s: %v", path, name, err)
+				}
+				if body != expect[path][try] {
+					t.Fatalf("unexpected %s body %d from %s: %s", path, try, name, body)
+				}
+				cached := try > 0 && path != "nostore"
+				if r.CacheHit != cached {
+					t.Fatalf("unexpected cache status of %s %d from %s: %v", path, try, name, r.CacheHit)
+				}
+				if r.StatusCode != 200 {
+					t.Fatalf("unexpected %s status from %s: %s", path, name, r.Status)
+				}
+			}
+		}
+		if n := hits.Load(); n != 6 {
+			t.Fatalf("unexpected number of %s server requests: %d", name, n)
+		}
+
+		r := c.NewURL("vary")
+		r.SetHeader("Accept", "text/plain")
+		if body, _ := r.Text(); r.CacheHit || body != "/vary #7" {
+			t.Fatalf("variant served from %s: %s", name, body)
+		}
+
+		c.Cache.Shared = true
+		r = c.NewURL("private")
+		r.Text()
+		r = c.NewURL("private")
+		if body, _ := r.Text(); r.CacheHit || body != "/private #9" {
+			t.Fatalf("private response shared by %s: %s", name, body)
+		}
+	}
+}
+
+func TestCacheConditional(t *testing.T) {
+	var hits atomic.Int32
+	s := cacheServer(&hits)
+	defer s.Close()
+	c := NewURL(s.URL)
+	c.SetCache(NewMemoryCache(0))
+	c.NewURL("fresh").Send()
+
+	r := c.NewURL("fresh")
+	r.SetIfNoneMatch("v1")
+	if _, err := r.Bytes(); err != ErrNotModified {
+		t.Fatalf("unexpected error for unmodified response: %v", err)
+	}
+	if !r.CacheHit || !r.NotModified() {
+		t.Fatalf("unexpected response for cached precondition: %s", r.Status)
+	}
+
+	r = c.NewURL("fresh")
+	r.SetIfNoneMatch("v0")
+	if body, err := r.Text(); err != nil || body != "/fresh #1" {
+		t.Fatalf("unexpected response for outdated precondition: %s", body)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("unexpected number of server requests: %d", n)
+	}
+}
+
+func TestCacheConditionalPoll(t *testing.T) {
+	var hits atomic.Int32
+	s := cacheServer(&hits)
+	defer s.Close()
+	c := NewURL(s.URL)
+	c.SetCache(NewMemoryCache(0))
+
+	r := c.NewURL("poll")
+	r.SetIfNoneMatch("v1")
+	if _, err := r.Bytes(); err != ErrNotModified || r.CacheHit {
+		t.Fatalf("unexpected result of conditional poll: %v", err)
+	}
+
+	r = c.NewURL("poll")
+	if body, err := r.Text(); err != nil || r.CacheHit || body != "/poll #2" {
+		t.Fatalf("unexpected response after conditional poll: %s (%v)", body, err)
+	}
+	r = c.NewURL("poll")
+	if body, err := r.Text(); err != nil || !r.CacheHit || body != "/poll #2" {
+		t.Fatalf("unexpected cached response: %s (%v)", body, err)
+	}
+}
+
+func TestCacheStale(t *testing.T) {
+	var hits atomic.Int32
+	s := cacheServer(&hits)
+	defer s.Close()
+	c := NewURL(s.URL)
+	c.SetCache(NewMemoryCache(0))
+	c.NewURL("swr").Send()
+
+	r := c.NewURL("swr")
+	if body, err := r.Text(); err != nil || body != "/swr #1" {
+		t.Fatalf("unexpected stale response: %s (%v)", body, err)
+	}
+	if !r.Stale || !r.CacheHit {
+		t.Fatalf("response not marked stale: %v", r.Stale)
+	}
+	waitRefresh(t, c.Cache)
+	r = c.NewURL("swr")
+	if body, _ := r.Text(); body != "/swr #2" {
+		t.Fatalf("unexpected revalidated response: %s", body)
+	}
+	waitRefresh(t, c.Cache)
+
+	stored, _ := c.NewURL("sie").Text()
+	r = c.NewURL("sie")
+	r.SetHeader("X-Fail", true)
+	if body, err := r.Text(); err != nil || body != stored {
+		t.Fatalf("unexpected response replacing error: %s (%v)", body, err)
+	}
+	if !r.Stale || r.StatusCode != 200 {
+		t.Fatalf("unexpected response status replacing error: %s", r.Status)
+	}
+
+	stored, _ = c.NewURL("swr/nocache").Text()
+	r = c.NewURL("swr/nocache")
+	if body, err := r.Text(); err != nil || r.Stale || body == stored {
+		t.Fatalf("unexpected response requiring revalidation: %s (%v)", body, err)
+	}
+	c.NewURL("sie/revalidate").Send()
+	r = c.NewURL("sie/revalidate")
+	r.SetHeader("X-Fail", true)
+	if r.Send(); r.Stale || r.StatusCode != http.StatusBadGateway {
+		t.Fatalf("error replaced despite must-revalidate: %s", r.Status)
+	}
+}
+
+// Wait for any background revalidation of a cache to complete.
+func waitRefresh(t *testing.T, c *Cache) {
+	for i := 0; ; i++ {
+		c.mu.Lock()
+		busy := len(c.refreshing)
+		c.mu.Unlock()
+		if busy == 0 {
+			return
+		}
+		if i > 100 {
+			t.Fatalf("background revalidation did not complete")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCacheMemoryLimit(t *testing.T) {
+	m := NewMemoryCache(2)
+	m.Set("a", []byte("1"))
+	m.Set("b", []byte("2"))
+	m.Get("a") // recently used
+	m.Set("c", []byte("3"))
+	if _, found := m.Get("b"); found {
+		t.Fatalf("least recently used entry retained")
+	}
+	if v, found := m.Get("a"); !found || string(v) != "1" {
+		t.Fatalf("recently used entry discarded: %s", v)
+	}
+	m.Delete("c")
+	if _, found := m.Get("c"); found {
+		t.Fatalf("deleted entry retained")
+	}
+}
+
+func TestCacheEntrySize(t *testing.T) {
+	var hits atomic.Int32
+	s := cacheServer(&hits)
+	defer s.Close()
+	c := NewURL(s.URL)
+	c.SetCache(NewMemoryCache(0))
+	c.Cache.MaxEntrySize = 5
+
+	for _, path := range []string{"fresh", "chunked"} {
+		for i := 0; i < 2; i++ {
+			r := c.NewURL(path)
+			if _, err := r.Text(); err != nil || r.CacheHit {
+				t.Fatalf("large response to %s stored: %v", path, err)
+			}
+		}
+	}
+	c.Cache.MaxEntrySize = 0 // default
+	c.NewURL("chunked").Send()
+	r := c.NewURL("chunked")
+	if r.Send(); r.CacheHit {
+		t.Fatalf("response of unknown length stored")
+	}
+}
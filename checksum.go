@@ -0,0 +1,181 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Expected digest of response bodies, verified once completely read
+// by [Bytes] and dependent methods.
+type Checksum struct {
+	// Hash function name such as "sha256", "sha512", "sha1" or "md5".
+	// Left empty to use the strongest digest header of a response.
+	Algorithm string
+	// Hash value to compare with,
+	// or nil to verify against digest headers if present.
+	Sum []byte
+}
+
+// Error type given by [Bytes] (or any dependent result method)
+// if the body does not match its expected [Checksum].
+// Matches [ErrChecksumMismatch] for errors.Is comparisons.
+type ChecksumError struct {
+	Algorithm string
+	Expected  []byte
+	Actual    []byte
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("%s checksum %x does not match expected %x",
+		e.Algorithm, e.Actual, e.Expected)
+}
+
+func (e *ChecksumError) Unwrap() error {
+	return ErrChecksumMismatch
+}
+
+// Generic error matched by any [ChecksumError].
+var ErrChecksumMismatch = fmt.Errorf("response body checksum mismatch")
+
+// Hash functions supported by [Checksum], strongest first.
+var checksumAlgorithms = []string{"sha512", "sha256", "sha1", "md5"}
+
+func newHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case "sha512":
+		return sha512.New()
+	case "sha256":
+		return sha256.New()
+	case "sha1":
+		return sha1.New()
+	case "md5":
+		return md5.New()
+	}
+	return nil
+}
+
+// Normalise algorithm names of various digest headers,
+// such as SHA-256 to sha256, or SHA to its original sha1.
+func hashName(name string) string {
+	name = strings.ToLower(strings.ReplaceAll(name, "-", ""))
+	if name == "sha" {
+		name = "sha1"
+	}
+	return name
+}
+
+// Collect base64 hash values from response headers:
+// Repr-Digest and Content-Digest of RFC 9530 (`sha-256=:...:`),
+// Digest of RFC 3230 (`SHA-256=...`) and Content-MD5.
+// Only representation digests apply to partial content.
+func headerSums(h http.Header, partial bool) map[string][]byte {
+	sums := make(map[string][]byte)
+	fields := []string{"Repr-Digest", "Digest"}
+	if !partial {
+		fields = append(fields, "Content-Digest")
+		if v := h.Get("Content-MD5"); v != "" {
+			if sum, err := base64.StdEncoding.DecodeString(v); err == nil {
+				sums["md5"] = sum
+			}
+		}
+	}
+	for _, field := range fields {
+		for _, line := range h.Values(field) {
+			for _, item := range strings.Split(line, ",") {
+				name, value, found := strings.Cut(strings.TrimSpace(item), "=")
+				if !found {
+					continue
+				}
+				value = strings.Trim(value, ":") // structured byte sequence
+				sum, err := base64.StdEncoding.DecodeString(value)
+				if err != nil {
+					continue
+				}
+				if name = hashName(name); sums[name] == nil {
+					sums[name] = sum
+				}
+			}
+		}
+	}
+	return sums
+}
+
+// Determine the hash to compare a response body with,
+// either set by [SetChecksum] or given by response headers.
+// Returns an empty expectation if there is nothing to verify.
+func (r *Request) expectedSum(partial bool) (e ChecksumError) {
+	if r.Checksum == nil {
+		return
+	}
+	e.Algorithm, e.Expected = r.Checksum.Algorithm, r.Checksum.Sum
+	if e.Expected != nil || r.Response.Uncompressed {
+		return // decoded body unrelated to digest headers
+	}
+	sums := headerSums(r.Response.Header, partial)
+	if e.Algorithm != "" {
+		e.Expected = sums[e.Algorithm]
+		return
+	}
+	for _, algorithm := range checksumAlgorithms {
+		if sum := sums[algorithm]; sum != nil {
+			e.Algorithm, e.Expected = algorithm, sum
+			return
+		}
+	}
+	return
+}
+
+// Body wrapper comparing its hash at the end of contents.
+type hashReader struct {
+	io.ReadCloser
+	ChecksumError
+	hash hash.Hash
+}
+
+func (h *hashReader) Read(p []byte) (n int, err error) {
+	n, err = h.ReadCloser.Read(p)
+	h.hash.Write(p[:n])
+	if err == io.EOF {
+		h.Actual = h.hash.Sum(nil)
+		if !bytes.Equal(h.Actual, h.Expected) {
+			err = &h.ChecksumError
+		}
+	}
+	return
+}
+
+// Wrap a complete response body to verify its [Checksum] if expected.
+func (r *Request) hashReader(body io.ReadCloser) io.ReadCloser {
+	if r.Checksum == nil || r.StatusCode == http.StatusPartialContent {
+		return body
+	}
+	e := r.expectedSum(false)
+	h := newHash(e.Algorithm)
+	if e.Expected == nil || h == nil {
+		return body
+	}
+	return &hashReader{body, e, h}
+}
+
+// Compare the entire contents of a file with its expected [Checksum].
+func (r *Request) verifyFile(f io.ReadSeeker) error {
+	e := r.expectedSum(true)
+	h := newHash(e.Algorithm)
+	if e.Expected == nil || h == nil {
+		return nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := io.Copy(io.Discard, &hashReader{io.NopCloser(f), e, h})
+	return err
+}
@@ -0,0 +1,122 @@
+package httpclient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Deduplication of concurrent identical GET and HEAD requests,
+// sharing a single in-flight [Send] and its results.
+// Requests are considered identical by their URL,
+// as well as any of the given request Headers (like Vary).
+// Response bodies are only read into memory once another request joins,
+// so a single download is still streamed.
+type Coalescer struct {
+	Headers []string
+	mu      sync.Mutex
+	calls   map[string]*flight
+}
+
+// Results of a single [Send] shared by identical requests.
+type flight struct {
+	done     chan struct{}
+	waiters  int // identical requests joined while in progress
+	response *http.Response
+	body     []byte
+	err      error
+	attempt  int
+	cacheHit bool
+	stale    bool
+}
+
+// Error given to identical requests if the shared [Send] panicked.
+var errFlightPanic = fmt.Errorf("coalesced request panicked")
+
+func (c *Coalescer) key(r *Request) string {
+	var key strings.Builder
+	key.WriteString(r.Request.Method + " " + r.Request.URL.String())
+	for _, name := range c.Headers {
+		key.WriteString("\n" + name + ": ")
+		key.WriteString(strings.Join(r.Request.Header.Values(name), ","))
+	}
+	return key.String()
+}
+
+// Send a request unless an identical one is in progress,
+// and give each caller its own copy of the response.
+func (c *Coalescer) send(r *Request) error {
+	method := r.Request.Method
+	if r.Error != nil || method != "" && method != "GET" && method != "HEAD" ||
+		r.Request.Header.Get("Range") != "" { // partial contents of downloads
+		return r.send()
+	}
+
+	key := c.key(r)
+	c.mu.Lock()
+	if f, found := c.calls[key]; found {
+		f.waiters++
+		c.mu.Unlock()
+		<-f.done
+		return f.result(r)
+	}
+	if c.calls == nil {
+		c.calls = make(map[string]*flight)
+	}
+	f := &flight{done: make(chan struct{}), err: errFlightPanic}
+	c.calls[key] = f
+	c.mu.Unlock()
+	defer func() {
+		// also release waiters if send panics
+		c.mu.Lock()
+		if c.calls[key] == f {
+			delete(c.calls, key)
+		}
+		c.mu.Unlock()
+		close(f.done)
+	}()
+
+	f.err = r.send()
+	c.mu.Lock()
+	delete(c.calls, key) // later requests are sent anew
+	waiters := f.waiters
+	c.mu.Unlock()
+	if waiters == 0 {
+		return f.err // nothing to share, so the body remains streaming
+	}
+
+	if r.Response != nil {
+		body := io.Reader(r.Response.Body)
+		if r.MaxBodySize > 0 {
+			// enough to be refused by every reader
+			body = io.LimitReader(body, r.MaxBodySize+1)
+		}
+		var err error
+		f.body, err = io.ReadAll(body)
+		r.Response.Body.Close()
+		if f.err == nil {
+			f.err = err
+		}
+	}
+	f.response, f.attempt = r.Response, r.Attempt
+	f.cacheHit, f.stale = r.CacheHit, r.Stale
+	return f.result(r)
+}
+
+// Apply shared results to a request.
+func (f *flight) result(r *Request) error {
+	r.Response = nil
+	if f.response != nil {
+		res := new(http.Response)
+		*res = *f.response
+		res.Header = f.response.Header.Clone()
+		res.Body = io.NopCloser(bytes.NewReader(f.body))
+		r.Response = res
+	}
+	r.Attempt = f.attempt
+	r.CacheHit, r.Stale = f.cacheHit, f.stale
+	return f.err
+}
@@ -0,0 +1,128 @@
+package httpclient
+
+import (
+	"testing"
+
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func TestCoalesce(t *testing.T) {
+	var hits atomic.Int32
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		time.Sleep(50 * time.Millisecond) // until all requests are waiting
+		fmt.Fprintf(w, "%s #%d", r.Header.Get("Accept"), n)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	c := NewURL(s.URL)
+	c.SetCoalesce("Accept")
+
+	const workers = 5
+	bodies := make([]string, workers*2)
+	var wg sync.WaitGroup
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r := c.NewURL("config")
+			r.SetHeader("Accept", i%2)
+			bodies[i], _ = r.Text()
+		}(i)
+	}
+	wg.Wait()
+
+	if n := hits.Load(); n != 2 {
+		t.Fatalf("unexpected number of server requests: %d", n)
+	}
+	for i, body := range bodies {
+		if body != bodies[i%2] || body == "" {
+			t.Fatalf("unexpected shared body %d: %s", i, body)
+		}
+	}
+	if bodies[0] == bodies[1] {
+		t.Fatalf("requests with distinct headers shared: %s", bodies[0])
+	}
+
+	r := c.NewURL("config")
+	r.Post("unique")
+	if body, _ := r.Text(); body != " #3" {
+		t.Fatalf("unexpected unshared post: %s", body)
+	}
+}
+
+func TestCoalesceStream(t *testing.T) {
+	release := make(chan struct{})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "first")
+		w.(http.Flusher).Flush()
+		<-release
+		io.WriteString(w, " last")
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	defer close(release)
+	c := NewURL(s.URL)
+	c.SetCoalesce()
+
+	received := make(chan string)
+	go func() {
+		r := c.Clone()
+		if err := r.Send(); err != nil {
+			received <- err.Error()
+			return
+		}
+		head := make([]byte, 5)
+		io.ReadFull(r.Response.Body, head)
+		received <- string(head)
+	}()
+	select {
+	case v := <-received:
+		if v != "first" {
+			t.Fatalf("unexpected streamed start: %s", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("unshared body buffered before reading")
+	}
+}
+
+func TestCoalescePanic(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer s.Close()
+	c := NewURL(s.URL)
+	c.SetCoalesce()
+	var panicked atomic.Bool
+	c.SetAuth(AuthFunc(func(req *http.Request) error {
+		if !panicked.Swap(true) {
+			panic("authentication failure")
+		}
+		return nil
+	}))
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("missing panic of authenticator")
+			}
+		}()
+		c.Clone().Send()
+	}()
+	sent := make(chan error)
+	go func() {
+		sent <- c.Clone().Send()
+	}()
+	select {
+	case err := <-sent:
+		if err != nil {
+			t.Fatalf("could not send after panic: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("identical request blocked by earlier panic")
+	}
+}
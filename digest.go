@@ -0,0 +1,248 @@
+package httpclient
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// [Authenticator] by HTTP Digest access authentication (RFC 7616).
+//
+// An initial request is sent without credentials,
+// to be repeated once answered by a 401 Unauthorized Digest challenge.
+// The challenge is remembered, so any further requests sharing
+// the same Digest are authenticated in advance by counting its nonce,
+// until the server declares it stale.
+// MD5, SHA-256 and SHA-512-256 are supported, including their -sess
+// variants and hashed usernames, with a quality of protection of "auth".
+//
+//	r := httpclient.NewURL("http://appliance.local/status")
+//	r.SetAuth(httpclient.NewDigest("admin", password))
+type Digest struct {
+	Username string
+	Password string
+
+	mu        sync.Mutex
+	challenge *digestChallenge // last accepted, or nil before any response
+	count     uint32           // nonce uses
+}
+
+// Prepare [Digest] credentials.
+func NewDigest(username, password string) *Digest {
+	return &Digest{Username: username, Password: password}
+}
+
+// Parameters of a WWW-Authenticate Digest challenge.
+type digestChallenge struct {
+	Realm     string
+	Nonce     string
+	Opaque    string
+	Algorithm string // uppercase, MD5 if not given
+	Qop       string // "auth" or empty for RFC 2069 compatibility
+	Userhash  bool
+}
+
+// Set an Authorization header if a challenge was received earlier.
+func (d *Digest) Authenticate(req *http.Request) error {
+	d.mu.Lock()
+	c := d.challenge
+	if c == nil {
+		d.mu.Unlock()
+		return nil // awaiting a challenge
+	}
+	d.count++
+	count := d.count
+	d.mu.Unlock()
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	method := req.Method
+	if method == "" {
+		method = "GET"
+	}
+	req.Header.Set("Authorization", c.authorization(
+		d.Username, d.Password, method, req.URL.RequestURI(), hex.EncodeToString(nonce), count))
+	return nil
+}
+
+// Accept a new or stale Digest challenge,
+// but not the rejection of a current one (indicating invalid credentials).
+func (d *Digest) Challenge(res *http.Response) bool {
+	var c *digestChallenge
+	stale := false
+	for _, params := range parseChallenges(res.Header.Values("WWW-Authenticate"))["digest"] {
+		if offer := newDigestChallenge(params); offer != nil &&
+			(c == nil || digestStrength(offer.Algorithm) > digestStrength(c.Algorithm)) {
+			c = offer
+			stale = strings.EqualFold(params["stale"], "true")
+		}
+	}
+	if c == nil {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	sent := res.Request != nil && res.Request.Header.Get("Authorization") != ""
+	if sent && !stale && d.challenge != nil && d.challenge.Nonce == c.Nonce {
+		return false // credentials refused
+	}
+	d.challenge, d.count = c, 0
+	return true
+}
+
+// Interpret supported challenge parameters, or nil if unsupported.
+func newDigestChallenge(params map[string]string) *digestChallenge {
+	c := &digestChallenge{
+		Realm:     params["realm"],
+		Nonce:     params["nonce"],
+		Opaque:    params["opaque"],
+		Algorithm: strings.ToUpper(params["algorithm"]),
+		Userhash:  strings.EqualFold(params["userhash"], "true"),
+	}
+	if c.Algorithm == "" {
+		c.Algorithm = "MD5"
+	}
+	if c.Nonce == "" || digestStrength(c.Algorithm) == 0 {
+		return nil
+	}
+	if qop, found := params["qop"]; found {
+		for _, option := range strings.Split(qop, ",") {
+			if strings.TrimSpace(option) == "auth" {
+				c.Qop = "auth"
+			}
+		}
+		if c.Qop == "" {
+			return nil // only auth-int offered
+		}
+	}
+	return c
+}
+
+// Preference of supported algorithms, or 0 if unknown.
+func digestStrength(algorithm string) int {
+	switch strings.TrimSuffix(algorithm, "-SESS") {
+	case "MD5":
+		return 1
+	case "SHA-256":
+		return 2
+	case "SHA-512-256":
+		return 3
+	}
+	return 0
+}
+
+// Authorization header value of a single request.
+func (c *digestChallenge) authorization(username, password, method, uri, cnonce string, count uint32) string {
+	var h func() hash.Hash
+	switch strings.TrimSuffix(c.Algorithm, "-SESS") {
+	case "SHA-256":
+		h = sha256.New
+	case "SHA-512-256":
+		h = sha512.New512_256
+	default:
+		h = md5.New
+	}
+	digest := func(parts ...string) string {
+		sum := h()
+		sum.Write([]byte(strings.Join(parts, ":")))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+
+	secret := digest(username, c.Realm, password)
+	if strings.HasSuffix(c.Algorithm, "-SESS") {
+		secret = digest(secret, c.Nonce, cnonce)
+	}
+	nc := fmt.Sprintf("%08x", count)
+	var response string
+	if c.Qop == "" {
+		response = digest(secret, c.Nonce, digest(method, uri))
+	} else {
+		response = digest(secret, c.Nonce, nc, cnonce, c.Qop, digest(method, uri))
+	}
+	if c.Userhash {
+		username = digest(username, c.Realm)
+	}
+
+	params := []string{
+		digestUsername(username),
+		"realm=" + quoteString(c.Realm),
+		"uri=" + quoteString(uri),
+		"algorithm=" + c.Algorithm,
+		"nonce=" + quoteString(c.Nonce),
+	}
+	if c.Qop != "" {
+		params = append(params, "nc="+nc, "cnonce="+quoteString(cnonce), "qop="+c.Qop)
+	}
+	params = append(params, "response="+quoteString(response))
+	if c.Opaque != "" {
+		params = append(params, "opaque="+quoteString(c.Opaque))
+	}
+	if c.Userhash {
+		params = append(params, "userhash=true")
+	}
+	return "Digest " + strings.Join(params, ", ")
+}
+
+// Username parameter, or an RFC 8187 extended value if it cannot be
+// sent as a quoted-string, such as non-ASCII names (RFC 7616 section 3.4.4).
+func digestUsername(username string) string {
+	for i := 0; i < len(username); i++ {
+		if c := username[i]; c < 0x20 || c >= 0x7f {
+			return "username*=UTF-8''" + encodeValue(username, false)
+		}
+	}
+	return "username=" + quoteString(username)
+}
+
+// Quoted-string of RFC 9110 section 5.6.4, escaping quotes and backslashes.
+func quoteString(s string) string {
+	var out strings.Builder
+	out.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			out.WriteByte('\\')
+		}
+		out.WriteByte(s[i])
+	}
+	out.WriteByte('"')
+	return out.String()
+}
+
+// Parameters of all WWW-Authenticate challenges by lowercase scheme.
+//
+//	Digest realm="api", qop="auth", nonce="7ypf", Basic realm="api"
+func parseChallenges(values []string) map[string][]map[string]string {
+	challenges := make(map[string][]map[string]string)
+	for _, v := range values {
+		var params map[string]string
+		for {
+			v = strings.TrimLeft(v, " \t,")
+			if v == "" {
+				break
+			}
+			cut := strings.IndexAny(v, "=, \t")
+			if cut < 0 {
+				cut = len(v)
+			}
+			name := strings.ToLower(v[:cut])
+			rest := strings.TrimLeft(v[cut:], " \t")
+			if params != nil && strings.HasPrefix(rest, "=") {
+				params[name], v = parseParamValue(strings.TrimLeft(rest[1:], " \t"))
+				continue
+			}
+			params = make(map[string]string) // new scheme
+			challenges[name] = append(challenges[name], params)
+			v = rest
+		}
+	}
+	return challenges
+}
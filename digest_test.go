@@ -0,0 +1,115 @@
+package httpclient
+
+import (
+	"testing"
+
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+func TestDigestResponse(t *testing.T) {
+	// examples of RFC 7616 section 3.9.1
+	c := digestChallenge{
+		Realm:  "http-auth@example.org",
+		Nonce:  "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+		Opaque: "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS",
+		Qop:    "auth",
+	}
+	cnonce := "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"
+	cases := map[string]string{
+		"MD5":     `response="8ca523f5e9506fed4657c9700eebdbec"`,
+		"SHA-256": `response="753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1"`,
+	}
+	for algorithm, expected := range cases {
+		c.Algorithm = algorithm
+		auth := c.authorization("Mufasa", "Circle of Life", "GET", "/dir/index.html", cnonce, 1)
+		if !strings.Contains(auth, expected) || !strings.Contains(auth, "nc=00000001") {
+			t.Fatalf("unexpected %s authorization: %s", algorithm, auth)
+		}
+	}
+
+	c.Realm = `say "hi"`
+	auth := c.authorization("Jäsøn Doe", "Secret, or not?", "GET", "/doe.json", cnonce, 1)
+	if !strings.Contains(auth, `username*=UTF-8''J%C3%A4s%C3%B8n%20Doe, `) ||
+		strings.Contains(auth, "username=") {
+		t.Fatalf("unexpected non-ascii username: %s", auth)
+	}
+	params := parseChallenges([]string{auth})["digest"]
+	if len(params) != 1 || params[0]["realm"] != c.Realm {
+		t.Fatalf("unexpected quoted realm: %s", auth)
+	}
+}
+
+func TestParseChallenges(t *testing.T) {
+	h := []string{
+		`Basic realm="api", Digest realm="a, b", qop="auth,auth-int", nonce=abc, algorithm=SHA-256`,
+		`Bearer`,
+	}
+	challenges := parseChallenges(h)
+	if len(challenges) != 3 || challenges["basic"][0]["realm"] != "api" {
+		t.Fatalf("unexpected challenges: %v", challenges)
+	}
+	c := newDigestChallenge(challenges["digest"][0])
+	if c == nil || c.Realm != "a, b" || c.Nonce != "abc" || c.Qop != "auth" || c.Algorithm != "SHA-256" {
+		t.Fatalf("unexpected digest challenge: %+v", c)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	var refused, nonces atomic.Int32
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce := fmt.Sprintf("n%d", nonces.Load())
+		c := digestChallenge{Realm: "test", Nonce: nonce, Algorithm: "SHA-256", Qop: "auth"}
+		params := parseChallenges([]string{r.Header.Get("Authorization")})["digest"]
+		if len(params) == 1 && params[0]["nonce"] == nonce {
+			p := params[0]
+			nc, _ := strconv.ParseUint(p["nc"], 16, 32)
+			expected := c.authorization("user", "secret", r.Method, p["uri"], p["cnonce"], uint32(nc))
+			if r.Header.Get("Authorization") == expected {
+				fmt.Fprint(w, p["nc"])
+				return
+			}
+		}
+		refused.Add(1)
+		stale := ""
+		if len(params) == 1 && params[0]["nonce"] != nonce {
+			stale = ", stale=true"
+		}
+		w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+		w.Header().Add("WWW-Authenticate", fmt.Sprintf(
+			`Digest realm="test", qop="auth", algorithm=SHA-256, nonce=%q%s`, nonce, stale))
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	s := httptest.NewServer(h)
+	defer s.Close()
+	c := NewURL(s.URL)
+	auth := NewDigest("user", "secret")
+	c.SetAuth(auth)
+
+	for i, expected := range []string{"00000001", "00000002"} {
+		if body, err := c.NewURL("status").Text(); err != nil || body != expected {
+			t.Fatalf("unexpected response %d: %s (%v)", i, body, err)
+		}
+	}
+	if n := refused.Load(); n != 1 {
+		t.Fatalf("nonce not reused: %d challenges", n)
+	}
+
+	nonces.Add(1) // expire nonce
+	if body, err := c.NewURL("status").Text(); err != nil || body != "00000001" {
+		t.Fatalf("stale nonce not renewed: %s (%v)", body, err)
+	}
+
+	auth.Password = "wrong"
+	r := c.NewURL("status")
+	if err := r.Send(); err != nil || r.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("invalid credentials not refused: %v", err)
+	}
+	if n := refused.Load(); n != 3 {
+		t.Fatalf("invalid credentials repeated: %d challenges", n)
+	}
+}
@@ -0,0 +1,356 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Save the response body of a successful [Send] to a file,
+// streaming to a temporary path+".part" which is atomically renamed
+// once all data has been received.
+//
+// An existing partial file is resumed by requesting the remaining bytes
+// with a Range header, guarded by If-Range so changed contents are
+// downloaded again in full.
+// Interrupted transfers are continued from the last received byte
+// for as many [Tries] as configured by [SetRetry].
+// Any [Checksum] is verified over the entire file.
+//
+//	r := httpclient.NewURL("https://localhost/large.iso")
+//	r.SetRetry(3)
+//	err := r.Download("/tmp/large.iso")
+func (r *Request) Download(path string) (err error) {
+	part := path + ".part"
+	f, err := os.OpenFile(part, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	d := &download{Request: r, File: f, size: -1}
+	if d.offset, err = f.Seek(0, io.SeekEnd); err != nil {
+		return
+	}
+	if d.offset > 0 {
+		// modification time set to Last-Modified of an earlier attempt
+		if info, err := f.Stat(); err == nil {
+			d.validator = info.ModTime().UTC().Format(http.TimeFormat)
+		}
+	}
+	defer r.Request.Header.Del("If-Range")
+	defer r.Request.Header.Del("Range")
+
+	var delay backoff
+	for try := 1; ; try++ {
+		retry, err := d.fetch()
+		if !d.modified.IsZero() {
+			// remember version for later resumption
+			_ = os.Chtimes(part, d.modified, d.modified)
+		}
+		if err == nil {
+			break
+		}
+		if errors.Is(err, ErrChecksumMismatch) {
+			d.restart() // never resume corrupted contents
+		}
+		if !retry || try >= r.Tries {
+			return err
+		}
+		delay.wait()
+	}
+
+	if d.resumed {
+		// partial responses were not verified while reading
+		if err = r.verifyFile(f); err != nil {
+			d.restart()
+			return
+		}
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	return os.Rename(part, path)
+}
+
+// Error given by [Download] if the amount of data received
+// does not match the Content-Length or Content-Range announced.
+var ErrBodyIncomplete = fmt.Errorf("response body incomplete")
+
+// Error given by [DownloadParallel] if the server no longer returns
+// parts of the contents announced by the initial request.
+var ErrContentChanged = fmt.Errorf("contents changed during download")
+
+// Progress of a single [Download] to be resumed by each attempt.
+type download struct {
+	*Request
+	File      *os.File
+	offset    int64     // bytes written
+	size      int64     // total expected, or -1 if unknown
+	validator string    // If-Range value of the partial contents
+	modified  time.Time // Last-Modified of the partial contents
+	resumed   bool      // appended to earlier contents
+}
+
+// Send a single request for any missing data and append it to the file.
+// Reports whether a failure may be resolved by trying again.
+func (d *download) fetch() (retry bool, err error) {
+	h := d.Request.Request.Header
+	if d.offset > 0 {
+		h.Set("Range", fmt.Sprintf("bytes=%d-", d.offset))
+		if d.validator != "" {
+			h.Set("If-Range", d.validator)
+		} else {
+			h.Del("If-Range")
+		}
+	}
+	if err = d.Send(); err != nil {
+		return
+	}
+	defer d.Response.Body.Close()
+
+	start, total := parseContentRange(d.Response.Header.Get("Content-Range"))
+	switch {
+	case d.offset == 0:
+		// regular download expected
+	case d.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		if total == d.offset {
+			d.size = total
+			d.resumed = true
+			return false, nil // already complete
+		}
+		return true, d.restart()
+	case d.StatusCode == http.StatusPartialContent:
+		if start != d.offset {
+			return true, d.restart()
+		}
+		d.resumed = true
+	case d.Success():
+		// full contents replaced by an updated version
+		if err = d.restart(); err != nil {
+			return
+		}
+	}
+	if err = d.Receive(); err != nil {
+		return
+	}
+
+	h = d.Response.Header
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		d.validator = etag // strong validator preferred
+	} else if modified := h.Get("Last-Modified"); modified != "" {
+		d.validator = modified
+	}
+	d.modified, _ = http.ParseTime(h.Get("Last-Modified"))
+	if total >= 0 {
+		d.size = total
+	} else if d.Response.ContentLength >= 0 {
+		d.size = d.offset + d.Response.ContentLength
+	}
+
+	body, err := d.body()
+	if err != nil {
+		return
+	}
+	n, err := io.Copy(d.File, body)
+	d.offset += n
+	if err != nil {
+		return retryable(err), err
+	}
+	if d.size >= 0 && d.offset != d.size {
+		return true, ErrBodyIncomplete
+	}
+	return
+}
+
+// Determine if a failure to copy a response body was caused by
+// an interrupted transfer, instead of a local or content problem.
+func retryable(err error) bool {
+	var fserr *fs.PathError
+	return !errors.As(err, &fserr) && !errors.Is(err, ErrBodyTooLarge) &&
+		!errors.Is(err, ErrChecksumMismatch) && !errors.Is(err, ErrContentChanged)
+}
+
+// Discard any partial contents to download everything again.
+func (d *download) restart() (err error) {
+	d.offset = 0
+	d.size = -1
+	d.validator = ""
+	d.resumed = false
+	d.Request.Request.Header.Del("Range")
+	d.Request.Request.Header.Del("If-Range")
+	if err = d.File.Truncate(0); err == nil {
+		_, err = d.File.Seek(0, io.SeekStart)
+	}
+	return
+}
+
+// Interpret a Content-Range header of "bytes start-end/total"
+// or "bytes */total", giving -1 for any unknown values.
+func parseContentRange(v string) (start, total int64) {
+	start, total = -1, -1
+	v, found := strings.CutPrefix(v, "bytes ")
+	if !found {
+		return
+	}
+	span, size, _ := strings.Cut(v, "/")
+	if _, err := fmt.Sscanf(size, "%d", &total); err != nil {
+		total = -1
+	}
+	if first, _, found := strings.Cut(span, "-"); found {
+		if _, err := fmt.Sscanf(first, "%d", &start); err != nil {
+			start = -1
+		}
+	}
+	return
+}
+
+// Download a file in a number of concurrent parts,
+// to be combined at the same temporary path as [Download].
+//
+// The size is determined by a HEAD request,
+// after which each part is requested by a [Clone] with a distinct Range,
+// so any headers, authentication and retries apply to all of them.
+// Any [OnProgress] hook receives their combined progress,
+// one report at a time.
+// Servers not advertising Accept-Ranges (or refusing HEAD)
+// are downloaded as a single stream.
+//
+//	r := httpclient.NewURL("https://localhost/large.iso")
+//	err := r.DownloadParallel("/tmp/large.iso", 4)
+func (r *Request) DownloadParallel(path string, n int) (err error) {
+	if n < 2 || r.Method != "" && r.Method != "GET" {
+		return r.Download(path)
+	}
+	probe := r.Clone()
+	probe.Method = "HEAD"
+	if err = probe.Send(); err == nil {
+		err = probe.Receive()
+	}
+	if probe.Response != nil {
+		probe.Response.Body.Close()
+	}
+	if err != nil {
+		return r.Download(path) // size unknown without HEAD support
+	}
+	h := probe.Response.Header
+	size := probe.Response.ContentLength
+	if size <= 0 || h.Get("Accept-Ranges") != "bytes" {
+		return r.Download(path)
+	}
+	validator := h.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = h.Get("Last-Modified")
+	}
+
+	part := path + ".part"
+	f, err := os.Create(part)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if err = f.Truncate(size); err != nil {
+		return
+	}
+
+	var progress io.Writer
+	if r.OnProgress != nil {
+		progress = &sharedProgress{progressMeter: r.progressMeter(size, false)}
+	}
+	chunk := (size + int64(n) - 1) / int64(n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range errs {
+		start := int64(i) * chunk
+		end := start + chunk - 1
+		if end >= size {
+			end = size - 1
+		}
+		if start > end {
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = r.downloadChunk(f, start, end, validator, progress)
+		}(i)
+	}
+	wg.Wait()
+	if err = errors.Join(errs...); err != nil {
+		return
+	}
+
+	if err = probe.verifyFile(f); err != nil {
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	if modified, err := http.ParseTime(h.Get("Last-Modified")); err == nil {
+		_ = os.Chtimes(part, modified, modified)
+	}
+	return os.Rename(part, path)
+}
+
+// Write a single byte range of a [DownloadParallel] into place,
+// continuing from the last received byte on interrupted transfers.
+// Written data is also counted by any combined progress.
+func (r *Request) downloadChunk(f *os.File, start, end int64, validator string, progress io.Writer) (err error) {
+	c := r.Clone()
+	c.OnProgress = nil // reported by progress instead
+	if validator != "" {
+		c.Request.Header.Set("If-Range", validator)
+	}
+	var delay backoff
+	for try := 1; ; try++ {
+		var n int64
+		n, err = c.fetchChunk(f, start, end, progress)
+		start += n
+		if err == nil || !retryable(err) || try >= r.Tries {
+			return
+		}
+		delay.wait()
+	}
+}
+
+// Request a byte range and copy its contents to the same file offset.
+func (r *Request) fetchChunk(f *os.File, start, end int64, progress io.Writer) (n int64, err error) {
+	r.Request.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	if err = r.Send(); err == nil {
+		err = r.Receive()
+	}
+	if err != nil {
+		if r.Response != nil {
+			r.Response.Body.Close()
+		}
+		return
+	}
+	defer r.Response.Body.Close()
+	if r.StatusCode != http.StatusPartialContent {
+		// contents changed since the initial request
+		return 0, fmt.Errorf("range %d-%d not returned: %s: %w", start, end, r.Status, ErrContentChanged)
+	}
+	if first, _ := parseContentRange(r.Response.Header.Get("Content-Range")); first != start {
+		return 0, fmt.Errorf("range %d-%d returned from offset %d", start, end, first)
+	}
+
+	body, err := r.body()
+	if err != nil {
+		return
+	}
+	var w io.Writer = io.NewOffsetWriter(f, start)
+	if progress != nil {
+		w = io.MultiWriter(w, progress)
+	}
+	n, err = io.Copy(w, io.LimitReader(body, end+1-start))
+	if err == nil && n != end+1-start {
+		err = ErrBodyIncomplete
+	}
+	return
+}
@@ -3,6 +3,7 @@ package httpclient
 import (
 	"testing"
 
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,7 +13,10 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -40,6 +44,57 @@ func serverClient() *httptest.Server {
 			w.Write([]byte(sampleHtml))
 		case "/xml":
 			w.Write([]byte(sampleXml))
+		case "/file", "/file/broken", "/file/nohead", "/file/changed":
+			if r.URL.Path == "/file/nohead" && r.Method == "HEAD" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			if r.URL.Path == "/file/broken" && r.Method == "GET" && r.Header.Get("Range") == "" {
+				// abort halfway through the initial attempt
+				w.Header().Set("Content-Length", strconv.Itoa(len(sampleFile)))
+				w.Write([]byte(sampleFile[:len(sampleFile)/2]))
+				w.(http.Flusher).Flush()
+				panic(http.ErrAbortHandler)
+			}
+			if end, found := strings.CutPrefix(r.Header.Get("Range"), "bytes=0-"); found && r.URL.Path == "/file/broken" {
+				// abort halfway through the first chunk
+				size, _ := strconv.Atoi(end)
+				size++
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", size-1, len(sampleFile)))
+				w.Header().Set("Content-Length", strconv.Itoa(size))
+				w.WriteHeader(http.StatusPartialContent)
+				w.Write([]byte(sampleFile[:size/2]))
+				w.(http.Flusher).Flush()
+				panic(http.ErrAbortHandler)
+			}
+			modified := sampleModified
+			if r.URL.Path == "/file/changed" && r.Method == "GET" {
+				modified = modified.Add(time.Hour) // updated after HEAD
+			}
+			body := strings.NewReader(sampleFile)
+			http.ServeContent(w, r, "file.txt", modified, body)
+		case "/pages":
+			// list 5 items by 2 per page, cursor, offset or page number
+			q := r.URL.Query()
+			start, _ := strconv.Atoi(q.Get("cursor") + q.Get("offset"))
+			if page, err := strconv.Atoi(q.Get("page")); err == nil {
+				start = (page - 1) * 2
+			}
+			out := struct {
+				Items []int
+				Next  any
+			}{[]int{}, nil}
+			for i := start; i < start+2 && i < 5; i++ {
+				out.Items = append(out.Items, i+1)
+			}
+			if start+2 < 5 {
+				out.Next = strconv.Itoa(start + 2)
+				w.Header().Set("X-Next", strconv.Itoa(start+2))
+				w.Header().Add("Link", `</first>; rel="first"`)
+				w.Header().Add("Link", fmt.Sprintf(`<pages?page=%d>; rel="next last"`, start/2+2))
+			}
+			json, _ := json.Marshal(&out)
+			w.Write(json)
 		case "/delay":
 			amount, err := strconv.Atoi(r.URL.Query().Get("ms"))
 			if err == nil {
@@ -118,6 +173,9 @@ func TestInvalid(t *testing.T) {
 	}
 }
 
+var sampleFile = strings.Repeat(sampleText+"\n", 1000)
+var sampleModified = time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+
 type HttpbinEcho struct {
 	Origin  string
 	Method  string
@@ -291,6 +349,36 @@ func TestClientResend(t *testing.T) {
 	}
 }
 
+func TestClientAuth(t *testing.T) {
+	var calls atomic.Int32
+	c := client.Clone()
+	c.SetAuth(AuthFunc(func(req *http.Request) error {
+		req.Header.Set("X-Signature", strconv.Itoa(int(calls.Add(1))))
+		return nil
+	}))
+	t.Parallel()
+
+	r := c.NewURL("status/500")
+	r.SetRetry(2)
+	if err := r.Send(); err != nil {
+		t.Fatalf("error downloading %s: %v", r.Request.URL, err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("authenticated %d of 3 attempts", n)
+	}
+	if v := r.Request.Header.Get("X-Signature"); v != "" {
+		t.Fatalf("authentication kept in request: %s", v)
+	}
+
+	var echo HttpbinEcho
+	if err := c.NewURL("anything").Json(&echo); err != nil {
+		t.Fatalf("error downloading echo: %v", err)
+	}
+	if v := echo.Headers["X-Signature"]; v != "4" {
+		t.Fatalf("unexpected authentication header: %q", v)
+	}
+}
+
 func TestClientTimeout(t *testing.T) {
 	r := client.NewURL("delay?ms=150")
 	r.SetTimeout(.1) // insufficient for slightly longer response
@@ -314,3 +402,194 @@ func TestClientTimeout(t *testing.T) {
 		t.Fatalf("download with increased timeout failed as well: %v", err)
 	}
 }
+
+func TestClientDownload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	r := client.NewURL("file")
+	if err := r.Download(path); err != nil {
+		t.Fatalf("could not download %s: %v", r.URL, err)
+	}
+	if v, _ := os.ReadFile(path); string(v) != sampleFile {
+		t.Fatalf("unexpected download contents of %d bytes", len(v))
+	}
+	if info, _ := os.Stat(path); !info.ModTime().Equal(sampleModified) {
+		t.Fatalf("unexpected modification time: %v", info.ModTime())
+	}
+	if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+		t.Fatalf("partial file remains after download: %v", err)
+	}
+
+	// resume from an earlier partial download
+	os.WriteFile(path+".part", []byte(sampleFile[:100]), 0666)
+	os.Chtimes(path+".part", sampleModified, sampleModified)
+	r = client.NewURL("file")
+	r.SetChecksum("sha256", fmt.Sprintf("%x", sha256.Sum256([]byte(sampleFile))))
+	if err := r.Download(path); err != nil {
+		t.Fatalf("could not resume %s: %v", r.URL, err)
+	}
+	if r.StatusCode != http.StatusPartialContent {
+		t.Fatalf("unexpected resumption status: %s", r.Status)
+	}
+	if v, _ := os.ReadFile(path); string(v) != sampleFile {
+		t.Fatalf("unexpected resumed contents of %d bytes", len(v))
+	}
+	if v := r.Request.Header.Get("Range"); v != "" {
+		t.Fatalf("range header left in request: %s", v)
+	}
+
+	// outdated partial contents replaced entirely
+	os.WriteFile(path+".part", []byte("outdated"), 0666)
+	r = client.NewURL("file")
+	if err := r.Download(path); err != nil {
+		t.Fatalf("could not replace %s: %v", r.URL, err)
+	}
+	if v, _ := os.ReadFile(path); string(v) != sampleFile {
+		t.Fatalf("unexpected replaced contents of %d bytes", len(v))
+	}
+}
+
+func TestClientDownloadRetry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	r := client.NewURL("file/broken")
+	t.Parallel()
+
+	if err := r.Download(path); err == nil {
+		t.Fatalf("interrupted download of %s succeeded", r.URL)
+	}
+	if info, err := os.Stat(path + ".part"); err != nil || info.Size() == 0 {
+		t.Fatalf("missing partial download: %v", err)
+	}
+
+	path = filepath.Join(filepath.Dir(path), "retry.txt")
+	r.SetRetry(1)
+	if err := r.Download(path); err != nil {
+		t.Fatalf("could not continue %s: %v", r.URL, err)
+	}
+	if r.StatusCode != http.StatusPartialContent {
+		t.Fatalf("unexpected continuation status: %s", r.Status)
+	}
+	if v, _ := os.ReadFile(path); string(v) != sampleFile {
+		t.Fatalf("unexpected continued contents of %d bytes", len(v))
+	}
+}
+
+func TestClientProgress(t *testing.T) {
+	r := client.NewURL("anything")
+	var reports []Progress
+	r.SetProgress(60, func(p Progress) {
+		reports = append(reports, p)
+	})
+	input := strings.Repeat("upload", 100)
+	r.Post(input)
+	body, err := r.Bytes()
+	if err != nil {
+		t.Fatalf("could not post %s: %v", r.URL, err)
+	}
+
+	if len(reports) != 2 {
+		t.Fatalf("unexpected number of progress reports: %v", reports)
+	}
+	up := reports[0]
+	if !up.Upload || !up.Done || up.Bytes != int64(len(input)) || up.Total != up.Bytes {
+		t.Fatalf("unexpected upload report: %+v", up)
+	}
+	down := reports[1]
+	if down.Upload || !down.Done || down.Bytes != int64(len(body)) || down.Total != down.Bytes {
+		t.Fatalf("unexpected download report: %+v", down)
+	}
+	if down.Rate <= 0 {
+		t.Fatalf("missing transfer rate: %+v", down)
+	}
+}
+
+func TestClientDownloadParallel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	r := client.NewURL("file")
+	r.SetChecksum("sha256", fmt.Sprintf("%x", sha256.Sum256([]byte(sampleFile))))
+	if err := r.DownloadParallel(path, 3); err != nil {
+		t.Fatalf("could not download %s: %v", r.URL, err)
+	}
+	if v, _ := os.ReadFile(path); string(v) != sampleFile {
+		t.Fatalf("unexpected combined contents of %d bytes", len(v))
+	}
+	if v := r.Request.Header.Get("Range"); v != "" {
+		t.Fatalf("range header set in original request: %s", v)
+	}
+
+	r = client.NewURL("file/broken")
+	if err := r.DownloadParallel(path, 3); err == nil {
+		t.Fatalf("interrupted chunk of %s succeeded", r.URL)
+	}
+	r.SetRetry(2)
+	if err := r.DownloadParallel(path, 3); err != nil {
+		t.Fatalf("could not continue chunk of %s: %v", r.URL, err)
+	}
+	if v, _ := os.ReadFile(path); string(v) != sampleFile {
+		t.Fatalf("unexpected continued contents of %d bytes", len(v))
+	}
+
+	r = client.NewURL("file")
+	var reports []Progress
+	r.SetProgress(0, func(p Progress) {
+		reports = append(reports, p) // unsynchronised
+	})
+	if err := r.DownloadParallel(path, 3); err != nil {
+		t.Fatalf("could not download %s with progress: %v", r.URL, err)
+	}
+	for i, p := range reports {
+		if p.Total != int64(len(sampleFile)) || i > 0 && p.Bytes < reports[i-1].Bytes {
+			t.Fatalf("unexpected combined progress: %+v", p)
+		}
+	}
+	if last := reports[len(reports)-1]; !last.Done || last.Bytes != last.Total {
+		t.Fatalf("unexpected final progress: %+v", last)
+	}
+
+	r = client.NewURL("file/changed")
+	r.SetRetry(2)
+	start := time.Now()
+	if err := r.DownloadParallel(path, 3); !errors.Is(err, ErrContentChanged) {
+		t.Fatalf("unexpected error of changed contents: %v", err)
+	}
+	if time.Since(start) >= time.Second {
+		t.Fatalf("changed contents retried")
+	}
+
+	r = client.NewURL("file/nohead")
+	if err := r.DownloadParallel(path, 3); err != nil {
+		t.Fatalf("could not download %s without HEAD: %v", r.URL, err)
+	}
+	if v, _ := os.ReadFile(path); string(v) != sampleFile {
+		t.Fatalf("unexpected fallback contents of %d bytes", len(v))
+	}
+
+	r = client.NewURL("xml") // no ranges
+	if err := r.DownloadParallel(path, 3); err != nil {
+		t.Fatalf("could not download %s: %v", r.URL, err)
+	}
+	if v, _ := os.ReadFile(path); string(v) != sampleXml {
+		t.Fatalf("unexpected fallback contents: %s", v)
+	}
+}
+
+func TestClientInvalidURL(t *testing.T) {
+	r := client.NewURL("status/%zz")
+	if r.Error == nil {
+		t.Fatalf("parse error of %s not kept", r.Request.URL)
+	}
+	err := r.Send()
+	var urlerr *url.Error
+	if !errors.As(err, &urlerr) || urlerr.Op != "parse" {
+		t.Fatalf("unexpected error sending invalid url: %v", err)
+	}
+	if v := r.Response; v != nil {
+		t.Fatalf("request sent to previous url: %s", v.Request.URL)
+	}
+
+	defer func() {
+		if v := recover(); v == nil {
+			t.Fatalf("missing panic on invalid url")
+		}
+	}()
+	MustNewURL("http://[::1")
+}
@@ -2,4 +2,4 @@ module github.com/inno-nl/go-http-client/v2
 
 go 1.19
 
-require golang.org/x/text v0.14.0 // indirect
+require golang.org/x/text v0.14.0
@@ -0,0 +1,50 @@
+package httpclient
+
+import (
+	"testing"
+
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+func TestHMAC(t *testing.T) {
+	// RFC 4231 test case 2
+	h := NewHMAC([]byte("Jefe"))
+	h.Template = "{body}"
+	h.TimestampHeader = ""
+	h.Prefix = "sha256="
+	r := NewURL("https://localhost/hook")
+	r.Post("what do ya want for nothing?")
+	req := r.Request.Clone(r.Request.Context())
+	if err := h.sign(req, time.Now()); err != nil {
+		t.Fatalf("could not sign: %v", err)
+	}
+	expected := "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	if v := req.Header.Get("X-Signature"); v != expected {
+		t.Fatalf("unexpected signature: %s", v)
+	}
+	if v := req.Header.Get("X-Timestamp"); v != "" {
+		t.Fatalf("unexpected timestamp: %s", v)
+	}
+
+	h = NewHMAC([]byte("key"))
+	h.TimeFormat = time.RFC3339
+	h.Template = "{method} {path}?{query}\n{timestamp}\n{bodyhash}"
+	r = NewURL("https://localhost/a%2Fb?x=1")
+	req = r.Request.Clone(r.Request.Context())
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := h.sign(req, now); err != nil {
+		t.Fatalf("could not sign: %v", err)
+	}
+	mac := hmac.New(sha256.New, []byte("key"))
+	mac.Write([]byte("GET /a%2Fb?x=1\n2024-01-02T03:04:05Z\n" +
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"))
+	if v := req.Header.Get("X-Signature"); v != hex.EncodeToString(mac.Sum(nil)) {
+		t.Fatalf("unexpected signature of template: %s", v)
+	}
+	if v := req.Header.Get("X-Timestamp"); v != "2024-01-02T03:04:05Z" {
+		t.Fatalf("unexpected timestamp: %s", v)
+	}
+}
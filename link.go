@@ -0,0 +1,159 @@
+package httpclient
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Web link given by a Link response header (RFC 8288).
+type Link struct {
+	URL    *url.URL          // target resolved against the response URL
+	Rel    []string          // relation types in lowercase, such as "next"
+	Type   string            // expected media type
+	Title  string            // label, decoded from title* if given
+	Params map[string]string // any other (extension) parameters
+}
+
+// All links of a response, in order of appearance.
+type Links []Link
+
+// First link of the given relation type, or nil if missing.
+//
+//	if next := r.Links().Rel("next"); next != nil {
+//		r = r.NewURL(next.URL.String())
+//	}
+func (l Links) Rel(rel string) *Link {
+	rel = strings.ToLower(rel)
+	for i := range l {
+		if slices.Contains(l[i].Rel, rel) {
+			return &l[i]
+		}
+	}
+	return nil
+}
+
+// Parse all Link headers of the received [Response],
+// skipping malformed entries.
+func (r *Request) Links() (links Links) {
+	if r.Response == nil {
+		return
+	}
+	base := r.Request.URL
+	if req := r.Response.Request; req != nil && req.URL != nil {
+		base = req.URL // final location after redirects
+	}
+	if base == nil {
+		base = new(url.URL)
+	}
+	for _, v := range r.Response.Header.Values("Link") {
+		for v != "" {
+			var link *Link
+			link, v = parseLink(v, base)
+			if link != nil {
+				links = append(links, *link)
+			}
+		}
+	}
+	return
+}
+
+// Interpret the first link-value of a header, returning the remainder.
+//
+//	<https://example.com/2>; rel="next last"; title*=UTF-8''%E2%86%92
+func parseLink(v string, base *url.URL) (link *Link, rest string) {
+	v = strings.TrimLeft(v, " \t,")
+	end := strings.IndexByte(v, '>')
+	if !strings.HasPrefix(v, "<") || end < 0 {
+		_, rest, _ = strings.Cut(v, ",") // skip malformed entry
+		return
+	}
+	target := v[1:end]
+	v = v[end+1:]
+
+	link = &Link{Params: make(map[string]string)}
+	seen := make(map[string]bool)
+	extTitle := false
+	for {
+		v = strings.TrimLeft(v, " \t")
+		if !strings.HasPrefix(v, ";") {
+			break
+		}
+		v = strings.TrimLeft(v[1:], " \t")
+		cut := strings.IndexAny(v, "=;, \t")
+		if cut < 0 {
+			cut = len(v)
+		}
+		name := strings.ToLower(v[:cut])
+		v = strings.TrimLeft(v[cut:], " \t")
+		value := ""
+		if strings.HasPrefix(v, "=") {
+			value, v = parseParamValue(strings.TrimLeft(v[1:], " \t"))
+		}
+		if seen[name] || name == "" {
+			continue // only the first occurrence counts
+		}
+		seen[name] = true
+
+		switch name {
+		case "rel":
+			link.Rel = strings.Fields(strings.ToLower(value))
+		case "type":
+			link.Type = value
+		case "title":
+			if !extTitle {
+				link.Title = value
+			}
+		case "title*":
+			// preferred over a plain title
+			if decoded, ok := decodeExtValue(value); ok {
+				link.Title, extTitle = decoded, true
+			}
+		default:
+			link.Params[name] = value
+		}
+	}
+
+	_, rest, _ = strings.Cut(v, ",") // ignore anything unexpected
+	u, err := base.Parse(target)
+	if err != nil {
+		return nil, rest
+	}
+	link.URL = u
+	return link, rest
+}
+
+// Read a token or quoted-string, returning the remainder.
+func parseParamValue(v string) (value string, rest string) {
+	if !strings.HasPrefix(v, `"`) {
+		cut := strings.IndexAny(v, ";, \t")
+		if cut < 0 {
+			cut = len(v)
+		}
+		return v[:cut], v[cut:]
+	}
+	var out strings.Builder
+	for i := 1; i < len(v); i++ {
+		switch c := v[i]; c {
+		case '\\':
+			if i++; i < len(v) {
+				out.WriteByte(v[i])
+			}
+		case '"':
+			return out.String(), v[i+1:]
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String(), "" // unterminated
+}
+
+// Decode an RFC 8187 extended value such as UTF-8'en'%E2%82%AC.
+func decodeExtValue(v string) (string, bool) {
+	parts := strings.SplitN(v, "'", 3)
+	if len(parts) != 3 || !strings.EqualFold(parts[0], "utf-8") {
+		return "", false
+	}
+	decoded, err := url.PathUnescape(parts[2])
+	return decoded, err == nil
+}
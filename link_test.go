@@ -0,0 +1,53 @@
+package httpclient
+
+import (
+	"testing"
+
+	"net/http"
+	"net/url"
+)
+
+func TestResponseLinks(t *testing.T) {
+	r := httpResult(200, "")
+	r.Request = &http.Request{URL: &url.URL{Scheme: "https", Host: "localhost", Path: "/api/items"}}
+	r.Response.Header.Add("Link", `<?page=2>; rel="next last"; type="application/json",`+
+		` </docs;v=1>; rel=DescribedBy; title="a, b"; title*=UTF-8'en'%E2%86%92; x-ext=val; rel=ignored`)
+	r.Response.Header.Add("Link", `invalid; rel=next, <//cdn.localhost/style.css>; rel=preload; as=style`)
+
+	links := r.Links()
+	if len(links) != 3 {
+		t.Fatalf("unexpected links: %+v", links)
+	}
+
+	next := links.Rel("Next")
+	if next == nil {
+		t.Fatalf("missing next link: %+v", links)
+	}
+	if v := next.URL.String(); v != "https://localhost/api/items?page=2" {
+		t.Fatalf("unexpected resolved url: %s", v)
+	}
+	if v := next.Type; v != "application/json" {
+		t.Fatalf("unexpected link type: %s", v)
+	}
+	if links.Rel("last") != next {
+		t.Fatalf("missing additional relation type: %v", next.Rel)
+	}
+
+	docs := links.Rel("describedby")
+	if docs == nil || docs.URL.Path != "/docs;v=1" {
+		t.Fatalf("unexpected described link: %+v", docs)
+	}
+	if v := docs.Title; v != "→" {
+		t.Fatalf("unexpected extended title: %s", v)
+	}
+	if v := docs.Params["x-ext"]; v != "val" {
+		t.Fatalf("unexpected extension parameters: %v", docs.Params)
+	}
+
+	if v := links.Rel("preload"); v == nil || v.URL.Host != "cdn.localhost" || v.Params["as"] != "style" {
+		t.Fatalf("unexpected preload link: %+v", v)
+	}
+	if v := links.Rel("ignored"); v != nil {
+		t.Fatalf("repeated relation parameter applied: %+v", v)
+	}
+}
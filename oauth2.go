@@ -0,0 +1,184 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// [Authenticator] by access tokens of the OAuth 2.0 client credentials
+// grant (RFC 6749 section 4.4), sent as Bearer Authorization.
+//
+// Tokens are requested when first needed and reused until shortly
+// before they expire. A request rejected by 401 Unauthorized is repeated
+// once with a new token, in case the previous one was revoked early.
+//
+// Tokens are requested by its Client if set, or otherwise by the client
+// of the authenticated request, so any proxy and TLS settings apply
+// to the token endpoint as well. Clients without a timeout are limited
+// to [OAuth2Timeout].
+//
+//	api.SetAuth(httpclient.NewOAuth2("https://localhost/token", id, secret, "read"))
+//	err := api.NewURL("items").Json(&items)
+type OAuth2 struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	Params       url.Values   // additional form fields, such as audience
+	Client       *http.Client // to request tokens, instead of the request's
+
+	// Renew tokens this long before they expire,
+	// or at most half of their lifetime.
+	// Defaults to a minute if zero.
+	RefreshBefore time.Duration
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time // zero if not given
+}
+
+// Prepare [OAuth2] client credentials for a token endpoint.
+func NewOAuth2(tokenURL, clientID, clientSecret string, scopes ...string) *OAuth2 {
+	return &OAuth2{
+		TokenURL:     tokenURL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Scopes:       scopes,
+	}
+}
+
+// Error response of a token endpoint (RFC 6749 section 5.2).
+type OAuth2Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+	URI         string `json:"error_uri"`
+}
+
+func (e *OAuth2Error) Error() string {
+	if e.Description == "" {
+		return fmt.Sprintf("oauth2 token refused: %s", e.Code)
+	}
+	return fmt.Sprintf("oauth2 token refused: %s: %s", e.Code, e.Description)
+}
+
+// Maximum duration of token requests by clients without a timeout,
+// as other requests are waiting for their result.
+var OAuth2Timeout = time.Minute
+
+// Set a current Bearer token, requested by a default client if needed.
+func (o *OAuth2) Authenticate(req *http.Request) error {
+	return o.AuthenticateWith(nil, req)
+}
+
+// Set a current Bearer token, requested by the given client if needed.
+func (o *OAuth2) AuthenticateWith(client *http.Client, req *http.Request) error {
+	token, err := o.current(client)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Renew a refused token, possibly revoked before its expiry.
+func (o *OAuth2) Challenge(res *http.Response) bool {
+	if res.Request != nil {
+		token, _ := strings.CutPrefix(res.Request.Header.Get("Authorization"), "Bearer ")
+		o.expire(token)
+	}
+	return true
+}
+
+// Current access token, requested from the token endpoint if needed.
+func (o *OAuth2) Token() (string, error) {
+	return o.current(nil)
+}
+
+func (o *OAuth2) current(client *http.Client) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.token != "" && (o.expiry.IsZero() || time.Now().Before(o.expiry)) {
+		return o.token, nil
+	}
+	o.token = ""
+	if err := o.fetch(client); err != nil {
+		return "", err
+	}
+	return o.token, nil
+}
+
+// Discard a token refused by the server,
+// unless it has already been replaced.
+func (o *OAuth2) expire(token string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.token == token {
+		o.token = ""
+	}
+}
+
+// Request a new token to replace the current one.
+func (o *OAuth2) fetch(client *http.Client) error {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(o.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.Scopes, " "))
+	}
+	for k, v := range o.Params {
+		form[k] = v
+	}
+
+	r := NewURL(o.TokenURL)
+	if o.Client != nil {
+		client = o.Client
+	}
+	if client != nil {
+		*r.Client = *client
+	}
+	if r.Client.Timeout == 0 {
+		r.Client.Timeout = OAuth2Timeout
+	}
+	// credentials form-encoded as required by RFC 6749 section 2.3.1
+	r.SetBasicAuth(url.QueryEscape(o.ClientID), url.QueryEscape(o.ClientSecret))
+	r.SetHeader("Content-Type", "application/x-www-form-urlencoded")
+	r.SetHeader("Accept", "application/json")
+	r.Post([]byte(form.Encode()))
+
+	var res struct {
+		OAuth2Error
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	err := r.Json(&res)
+	if res.Code != "" {
+		return &res.OAuth2Error
+	}
+	if err != nil {
+		return fmt.Errorf("oauth2 token request failed: %w", err)
+	}
+	if res.AccessToken == "" {
+		return fmt.Errorf("oauth2 token missing from response")
+	}
+	if res.TokenType != "" && !strings.EqualFold(res.TokenType, "bearer") {
+		return fmt.Errorf("oauth2 token type %s unsupported", res.TokenType)
+	}
+
+	o.token = res.AccessToken
+	o.expiry = time.Time{}
+	if res.ExpiresIn > 0 {
+		lifetime := time.Duration(res.ExpiresIn) * time.Second
+		margin := o.RefreshBefore
+		if margin == 0 {
+			margin = time.Minute
+		}
+		if margin > lifetime/2 {
+			margin = lifetime / 2
+		}
+		o.expiry = time.Now().Add(lifetime - margin)
+	}
+	return nil
+}
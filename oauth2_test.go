@@ -0,0 +1,101 @@
+package httpclient
+
+import (
+	"testing"
+
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Server issuing numbered tokens and accepting only the latest one.
+func oauth2Server(issued *atomic.Int32) *httptest.Server {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			id, secret, _ := r.BasicAuth()
+			secret, _ = url.QueryUnescape(secret)
+			if id != "client" || secret != "s&cret" || r.PostFormValue("grant_type") != "client_credentials" {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"error":"invalid_client"}`)
+				return
+			}
+			n := issued.Add(1)
+			fmt.Fprintf(w, `{"access_token":"t%d","token_type":"Bearer","expires_in":3600,"scope":%q}`,
+				n, r.PostFormValue("scope"))
+		default:
+			if r.Header.Get("Authorization") != fmt.Sprintf("Bearer t%d", issued.Load()) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, r.Header.Get("Authorization"))
+		}
+	})
+	return httptest.NewServer(h)
+}
+
+func TestOAuth2(t *testing.T) {
+	var issued atomic.Int32
+	s := oauth2Server(&issued)
+	defer s.Close()
+	c := NewURL(s.URL)
+	var routed atomic.Int32
+	c.Client.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/token" {
+			routed.Add(1)
+		}
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	auth := NewOAuth2(s.URL+"/token", "client", "s&cret", "read", "write")
+	c.SetAuth(auth)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if body, err := c.NewURL("api").Text(); err != nil || body != "Bearer t1" {
+				t.Errorf("unexpected concurrent result: %s (%v)", body, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := issued.Load(); n != 1 {
+		t.Fatalf("token requested %d times", n)
+	}
+	if n := routed.Load(); n != 1 {
+		t.Fatalf("token requested %d times by the client of the request", n)
+	}
+	if c.Request.Header.Get("Authorization") != "" {
+		t.Fatalf("authorization leaked into base request")
+	}
+
+	auth.expiry = time.Now() // about to expire
+	if body, _ := c.NewURL("api").Text(); body != "Bearer t2" {
+		t.Fatalf("expired token not refreshed: %s", body)
+	}
+
+	issued.Add(1) // revoke current token
+	r := c.NewURL("api")
+	r.Post("data")
+	if body, err := r.Text(); err != nil || body != "Bearer t4" {
+		t.Fatalf("revoked token not replaced: %s (%v)", body, err)
+	}
+	if r.Attempt != 1 {
+		t.Fatalf("renewal counted as attempt: %d", r.Attempt)
+	}
+
+	issued.Add(1) // refused renewal
+	auth.ClientSecret = "wrong"
+	err := c.NewURL("api").Send()
+	var oerr *OAuth2Error
+	if !errors.As(err, &oerr) || oerr.Code != "invalid_client" {
+		t.Fatalf("unexpected token error: %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Method to derive the request of the following page
+// from the last received one and its body contents,
+// or nil after the final page.
+type NextPage func(last *Request, body []byte) (*Request, error)
+
+// Error given by [Pager.Scan] if more than [MaxPages] are available.
+var ErrPageLimit = fmt.Errorf("maximum number of pages exceeded")
+
+// Iterator over consecutive pages of a list endpoint,
+// each received as [Json] into a new Page.
+// Following requests are cloned from the first,
+// so any headers, authentication and retries apply to all pages.
+//
+//	p := httpclient.NewPager[[]Item](api.NewURL("items"), httpclient.NextLink)
+//	p.MaxPages = 100
+//	for p.Scan() {
+//		for _, item := range p.Page {
+//			...
+//		}
+//	}
+//	if p.Err != nil {
+//		...
+//	}
+type Pager[T any] struct {
+	Next     *Request // upcoming page, or nil when done
+	NextPage NextPage // strategy to find the following page
+	MaxPages int      // refuse to continue after this number if positive
+	Count    int      // number of pages received
+	Page     T        // contents of the last page
+	Last     *Request // last page received
+	Err      error    // failure to end iteration
+}
+
+// Prepare a [Pager] starting at the given request.
+func NewPager[T any](first *Request, next NextPage) *Pager[T] {
+	return &Pager[T]{Next: first, NextPage: next}
+}
+
+// Download and decode the next page,
+// returning false when done or on failure reported in [Err].
+func (p *Pager[T]) Scan() bool {
+	if p.Err != nil || p.Next == nil {
+		return false
+	}
+	if p.MaxPages > 0 && p.Count >= p.MaxPages {
+		p.Err = ErrPageLimit
+		return false
+	}
+
+	r := p.Next
+	body, err := r.Bytes()
+	if err != nil {
+		p.Err = err
+		return false
+	}
+	r.Response.Body = io.NopCloser(bytes.NewReader(body)) // copy for rereading
+
+	var page T
+	switch {
+	case len(body) == 0:
+		err = ErrBodyEmpty
+	case body[0] == '<':
+		err = ErrJsonLikeXml
+	default:
+		err = json.Unmarshal(body, &page)
+	}
+	if err != nil {
+		p.Err = err
+		return false
+	}
+	p.Count++
+	p.Page, p.Last = page, r
+	p.Next, p.Err = p.NextPage(r, body) // ends the next scan if failed
+	return true
+}
+
+// Gather the items of all remaining pages, as selected from each.
+//
+//	p := httpclient.NewPager[Result](r, httpclient.NextCursor("cursor", "next"))
+//	users, err := httpclient.PageItems(p, func(page *Result) []User {
+//		return page.Users
+//	})
+func PageItems[T, I any](p *Pager[T], items func(*T) []I) (all []I, err error) {
+	for p.Scan() {
+		all = append(all, items(&p.Page)...)
+	}
+	return all, p.Err
+}
+
+// Follow a Link header with rel="next" (RFC 8288),
+// resolved relative to the final response URL.
+func NextLink(last *Request, body []byte) (*Request, error) {
+	next := last.Links().Rel("next")
+	if next == nil {
+		return nil, nil
+	}
+	d := last.Clone()
+	d.Response = nil         // to be received by Scan()
+	d.Request.URL = next.URL // entirely replaced including query
+	return d, nil
+}
+
+// Set a query parameter to a cursor token found in the JSON body
+// at the given path of object keys, until it is missing, null or empty.
+//
+//	NextCursor("cursor", "meta", "next") // {"meta":{"next":"abc"}} to ?cursor=abc
+func NextCursor(param string, field ...string) NextPage {
+	return func(last *Request, body []byte) (*Request, error) {
+		var v any
+		d := json.NewDecoder(bytes.NewReader(body))
+		d.UseNumber() // keep numeric tokens intact
+		if err := d.Decode(&v); err != nil {
+			return nil, err
+		}
+		for _, key := range field {
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return nil, nil
+			}
+			v = obj[key]
+		}
+		if v == nil || v == "" {
+			return nil, nil
+		}
+		return nextParam(last, param, v), nil
+	}
+}
+
+// Set a query parameter to a cursor token given by a response header,
+// until it is no longer sent.
+func NextCursorHeader(param string, header string) NextPage {
+	return func(last *Request, body []byte) (*Request, error) {
+		token := last.Response.Header.Get(header)
+		if token == "" {
+			return nil, nil
+		}
+		return nextParam(last, param, token), nil
+	}
+}
+
+// Increase an offset parameter by the number of items received,
+// until a page contains fewer than the given limit (or none if 0).
+// Items are counted in a JSON array, or the largest array of an object.
+func NextOffset(param string, limit int) NextPage {
+	return func(last *Request, body []byte) (*Request, error) {
+		n := jsonItems(body)
+		if n == 0 || n < limit {
+			return nil, nil
+		}
+		offset, err := intParam(last, param, 0)
+		if err != nil {
+			return nil, err
+		}
+		return nextParam(last, param, offset+n), nil
+	}
+}
+
+// Increase a page number parameter (starting at 1 if missing),
+// until a page contains no items as counted by [NextOffset].
+func NextPageNumber(param string) NextPage {
+	return func(last *Request, body []byte) (*Request, error) {
+		if jsonItems(body) == 0 {
+			return nil, nil
+		}
+		page, err := intParam(last, param, 1)
+		if err != nil {
+			return nil, err
+		}
+		return nextParam(last, param, page+1), nil
+	}
+}
+
+// Clone a request with a single query parameter replaced.
+func nextParam(last *Request, param string, v any) *Request {
+	d := last.Clone()
+	d.Response = nil // to be received by Scan()
+	d.SetParam(param, v)
+	return d
+}
+
+// Numeric value of a query parameter, or a default if missing.
+func intParam(r *Request, param string, init int) (int, error) {
+	v, found := r.GetParam(param)
+	if !found {
+		return init, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid pagination parameter %s: %w", param, err)
+	}
+	return n, nil
+}
+
+// Number of items in a JSON array, or the largest array in an object.
+func jsonItems(body []byte) (n int) {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return
+	}
+	switch v := v.(type) {
+	case []any:
+		return len(v)
+	case map[string]any:
+		for _, field := range v {
+			if list, ok := field.([]any); ok && len(list) > n {
+				n = len(list)
+			}
+		}
+	}
+	return
+}
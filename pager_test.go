@@ -0,0 +1,66 @@
+package httpclient
+
+import (
+	"testing"
+
+	"errors"
+	"fmt"
+)
+
+type samplePage struct {
+	Items []int
+	Next  *string
+}
+
+func TestPagerStrategies(t *testing.T) {
+	cases := map[string]NextPage{
+		"link":   NextLink,
+		"cursor": NextCursor("cursor", "Next"),
+		"header": NextCursorHeader("cursor", "X-Next"),
+		"offset": NextOffset("offset", 2),
+		"page":   NextPageNumber("page"),
+	}
+	for name, next := range cases {
+		r := client.NewURL("pages?other=kept")
+		p := NewPager[samplePage](r, next)
+		items, err := PageItems(p, func(page *samplePage) []int {
+			return page.Items
+		})
+		if err != nil {
+			t.Fatalf("could not paginate by %s: %v", name, err)
+		}
+		if v := fmt.Sprint(items); v != "[1 2 3 4 5]" {
+			t.Fatalf("unexpected items paginated by %s: %v", name, v)
+		}
+		if p.Count < 3 {
+			t.Fatalf("unexpected number of pages by %s: %d", name, p.Count)
+		}
+		if v, _ := p.Last.GetParam("other"); v != "kept" && name != "link" {
+			t.Fatalf("parameters lost paginating by %s: %s", name, p.Last.URL)
+		}
+	}
+}
+
+func TestPagerLimit(t *testing.T) {
+	p := NewPager[samplePage](client.NewURL("pages"), NextPageNumber("page"))
+	p.MaxPages = 2
+	pages := 0
+	for p.Scan() {
+		pages++
+	}
+	if pages != 2 {
+		t.Fatalf("unexpected number of pages: %d", pages)
+	}
+	if !errors.Is(p.Err, ErrPageLimit) {
+		t.Fatalf("unexpected error after page limit: %v", p.Err)
+	}
+
+	p = NewPager[samplePage](client.NewURL("missing"), NextLink)
+	if p.Scan() {
+		t.Fatalf("unexpected page from %s", p.Next.URL)
+	}
+	var e *StatusError
+	if !errors.As(p.Err, &e) || e.Code != 404 {
+		t.Fatalf("unexpected error: %v", p.Err)
+	}
+}
@@ -0,0 +1,84 @@
+package httpclient
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Transfer status reported to an [OnProgress] hook.
+type Progress struct {
+	Upload bool    // request body being sent instead of a response received
+	Bytes  int64   // amount transferred so far
+	Total  int64   // expected size from Content-Length, or -1 if unknown
+	Rate   float64 // average number of bytes per second
+	Done   bool    // final report after reaching the end of the body
+}
+
+// Transfer status calling a progress hook at most once per interval,
+// and always after the last data.
+type progressMeter struct {
+	Progress
+	hook     func(Progress)
+	interval time.Duration
+	start    time.Time
+	last     time.Time
+}
+
+func (r *Request) progressMeter(total int64, upload bool) progressMeter {
+	now := time.Now()
+	return progressMeter{
+		Progress: Progress{Upload: upload, Total: total},
+		hook:     r.OnProgress,
+		interval: r.ProgressInterval,
+		start:    now,
+		last:     now,
+	}
+}
+
+// Count transferred bytes, and report them if due.
+func (p *progressMeter) advance(n int64, done bool) {
+	p.Bytes += n
+	if p.Done {
+		return // already reported
+	}
+	p.Done = done
+	if now := time.Now(); p.Done || now.Sub(p.last) >= p.interval {
+		p.last = now
+		if elapsed := now.Sub(p.start).Seconds(); elapsed > 0 {
+			p.Rate = float64(p.Bytes) / elapsed
+		}
+		p.hook(p.Progress)
+	}
+}
+
+// Body wrapper reporting progress of reads.
+type progressReader struct {
+	io.ReadCloser
+	progressMeter
+}
+
+func (r *Request) progressReader(body io.ReadCloser, total int64, upload bool) *progressReader {
+	return &progressReader{body, r.progressMeter(total, upload)}
+}
+
+func (p *progressReader) Read(b []byte) (n int, err error) {
+	n, err = p.ReadCloser.Read(b)
+	p.advance(int64(n), err == io.EOF)
+	return
+}
+
+// Progress of concurrent transfers combined into serial reports,
+// counting data written by any of them.
+type sharedProgress struct {
+	mu sync.Mutex
+	progressMeter
+}
+
+func (p *sharedProgress) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := int64(len(b))
+	p.advance(n, p.Bytes+n >= p.Total)
+	return len(b), nil
+}
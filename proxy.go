@@ -0,0 +1,244 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Function to establish network connections like [net.Dialer.DialContext].
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// Whether a host (and port, if known) is excluded from proxying
+// by any of the rules of [SetNoProxy].
+func noProxy(rules []string, host, port string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	ip := net.ParseIP(host)
+	for _, rule := range rules {
+		rule = strings.ToLower(strings.TrimSpace(rule))
+		if rule == "*" {
+			return true
+		}
+		if _, network, err := net.ParseCIDR(rule); err == nil {
+			if ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if h, p, err := net.SplitHostPort(rule); err == nil {
+			if p != port {
+				continue
+			}
+			rule = h
+		}
+		rule = strings.Trim(rule, "[]") // bare IPv6
+		if ruleIP := net.ParseIP(rule); ruleIP != nil {
+			if ip != nil && ruleIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		domain := strings.TrimPrefix(strings.TrimPrefix(rule, "*"), ".")
+		if domain != "" && (host == domain || strings.HasSuffix(host, "."+domain)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Port of a request URL, or the default of its scheme.
+func urlPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	if u.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
+
+// Connections tunnelled through a SOCKS5 proxy (RFC 1928),
+// with optional username and password authentication (RFC 1929).
+// Target hosts are resolved locally for socks5:// URLs,
+// or by the proxy for socks5h://.
+//
+// The SOCKS support of [http.ProxyURL] is not used because it always
+// leaves resolution to the proxy, treating socks5:// like socks5h://,
+// so [SetResolve] and [SetResolver] would not apply to target hosts.
+type socksDialer struct {
+	proxy   *url.URL
+	dial    dialFunc          // connection to the proxy
+	bypass  []string          // rules of hosts to be connected directly
+	resolve map[string]string // addresses overriding target hosts
+	// Local resolution of socks5 targets, or the default resolver if nil.
+	lookup func(ctx context.Context, host string) ([]string, error)
+}
+
+func (s *socksDialer) DialContext(ctx context.Context, network, addr string) (conn net.Conn, err error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return
+	}
+	if noProxy(s.bypass, host, port) {
+		return s.dial(ctx, network, addr)
+	}
+	if target, found := resolveOverride(s.resolve, host, port); found {
+		if host, port, err = net.SplitHostPort(target); err != nil {
+			return
+		}
+	}
+	proxy := s.proxy.Host
+	if s.proxy.Port() == "" {
+		proxy = net.JoinHostPort(s.proxy.Hostname(), "1080")
+	}
+	if conn, err = s.dial(ctx, "tcp", proxy); err != nil {
+		return
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if err = s.connect(ctx, conn, host, port); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("socks5 proxy %s: %w", proxy, err)
+	}
+	conn.SetDeadline(time.Time{})
+	return
+}
+
+// Replies of a SOCKS5 server by code.
+var socksReplies = []string{
+	"succeeded",
+	"general server failure",
+	"connection not allowed by ruleset",
+	"network unreachable",
+	"host unreachable",
+	"connection refused",
+	"TTL expired",
+	"command not supported",
+	"address type not supported",
+}
+
+// Negotiate authentication and request a connection to the target.
+func (s *socksDialer) connect(ctx context.Context, conn net.Conn, host, port string) error {
+	portnum, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	methods := []byte{0x00} // no authentication
+	if s.proxy.User != nil {
+		methods = append(methods, 0x02) // username/password
+	}
+	if _, err = conn.Write(append([]byte{0x05, byte(len(methods))}, methods...)); err != nil {
+		return err
+	}
+	reply := make([]byte, 2)
+	if _, err = io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	switch {
+	case reply[0] != 0x05:
+		return fmt.Errorf("unexpected protocol version %d", reply[0])
+	case reply[1] == 0x02 && s.proxy.User != nil:
+		user := s.proxy.User.Username()
+		pass, _ := s.proxy.User.Password()
+		if len(user) > 255 || len(pass) > 255 {
+			return fmt.Errorf("credentials too long")
+		}
+		auth := []byte{0x01, byte(len(user))}
+		auth = append(auth, user...)
+		auth = append(append(auth, byte(len(pass))), pass...)
+		if _, err = conn.Write(auth); err != nil {
+			return err
+		}
+		if _, err = io.ReadFull(conn, reply); err != nil {
+			return err
+		}
+		if reply[1] != 0x00 {
+			return fmt.Errorf("authentication refused")
+		}
+	case reply[1] != 0x00:
+		return fmt.Errorf("no acceptable authentication method")
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil && s.proxy.Scheme == "socks5" {
+		// resolved locally, preferring IPv4
+		lookup := s.lookup
+		if lookup == nil {
+			lookup = net.DefaultResolver.LookupHost
+		}
+		addrs, err := lookup(ctx, host)
+		if err != nil {
+			return err
+		}
+		for _, addr := range addrs {
+			found := net.ParseIP(addr)
+			if found != nil && (ip == nil || ip.To4() == nil && found.To4() != nil) {
+				ip = found
+			}
+		}
+		if ip == nil {
+			return fmt.Errorf("no addresses found for %s", host)
+		}
+	}
+	req := []byte{0x05, 0x01, 0x00} // CONNECT
+	switch {
+	case ip.To4() != nil:
+		req = append(append(req, 0x01), ip.To4()...)
+	case ip != nil:
+		req = append(append(req, 0x04), ip.To16()...)
+	case len(host) > 255:
+		return fmt.Errorf("host name too long")
+	default:
+		req = append(append(req, 0x03, byte(len(host))), host...)
+	}
+	req = binary.BigEndian.AppendUint16(req, uint16(portnum))
+	if _, err = conn.Write(req); err != nil {
+		return err
+	}
+
+	head := make([]byte, 4)
+	if _, err = io.ReadFull(conn, head); err != nil {
+		return err
+	}
+	if head[1] != 0x00 {
+		if int(head[1]) < len(socksReplies) {
+			return fmt.Errorf("connection to %s failed: %s", host, socksReplies[head[1]])
+		}
+		return fmt.Errorf("connection to %s failed: code %d", host, head[1])
+	}
+	var skip int // bound address and port
+	switch head[3] {
+	case 0x01:
+		skip = 4 + 2
+	case 0x04:
+		skip = 16 + 2
+	case 0x03:
+		size := make([]byte, 1)
+		if _, err = io.ReadFull(conn, size); err != nil {
+			return err
+		}
+		skip = int(size[0]) + 2
+	default:
+		return fmt.Errorf("unexpected address type %d", head[3])
+	}
+	_, err = io.ReadFull(conn, make([]byte, skip))
+	return err
+}
+
+// Apply bypass rules to a proxy selection function.
+func bypassProxy(proxy func(*http.Request) (*url.URL, error), rules []string) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		if noProxy(rules, req.URL.Hostname(), urlPort(req.URL)) {
+			return nil, nil
+		}
+		return proxy(req)
+	}
+}
@@ -0,0 +1,200 @@
+package httpclient
+
+import (
+	"testing"
+
+	"context"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+)
+
+func TestNoProxy(t *testing.T) {
+	rules := []string{"localhost", ".internal", "*.corp", "10.0.0.0/8", "::1", "example.com:8080"}
+	cases := map[string]bool{
+		"localhost:80":         true,
+		"LOCALHOST.:80":        true,
+		"api.internal:443":     true,
+		"internal:443":         true,
+		"x.y.corp:80":          true,
+		"notcorp:80":           false,
+		"10.1.2.3:80":          true,
+		"11.1.2.3:80":          false,
+		"[::1]:80":             true,
+		"example.com:8080":     true,
+		"example.com:80":       false,
+		"sub.example.com:8080": true,
+		"example.org:8080":     false,
+	}
+	for addr, expected := range cases {
+		host, port, _ := net.SplitHostPort(addr)
+		if noProxy(rules, host, port) != expected {
+			t.Fatalf("unexpected bypass of %s: %v", addr, !expected)
+		}
+	}
+	if !noProxy([]string{"*"}, "anything", "1") {
+		t.Fatalf("wildcard not bypassed")
+	}
+}
+
+// Minimal SOCKS5 server requiring authentication,
+// reporting the address type of each request.
+func socksServer(t *testing.T, types chan<- byte) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 262)
+				io.ReadFull(conn, buf[:2])
+				io.ReadFull(conn, buf[:buf[1]])
+				conn.Write([]byte{0x05, 0x02})
+				io.ReadFull(conn, buf[:2]) // version and user length
+				user := make([]byte, buf[1])
+				io.ReadFull(conn, user)
+				io.ReadFull(conn, buf[:1])
+				pass := make([]byte, buf[0])
+				io.ReadFull(conn, pass)
+				if string(user) != "user" || string(pass) != "secret" {
+					conn.Write([]byte{0x01, 0x01})
+					return
+				}
+				conn.Write([]byte{0x01, 0x00})
+
+				io.ReadFull(conn, buf[:4])
+				types <- buf[3]
+				var host string
+				switch buf[3] {
+				case 0x01:
+					io.ReadFull(conn, buf[:4])
+					host = net.IP(buf[:4]).String()
+				case 0x03:
+					io.ReadFull(conn, buf[:1])
+					name := make([]byte, buf[0])
+					io.ReadFull(conn, name)
+					host = string(name)
+				}
+				io.ReadFull(conn, buf[:2])
+				port := strconv.Itoa(int(binary.BigEndian.Uint16(buf[:2])))
+				target, err := net.Dial("tcp", net.JoinHostPort(host, port))
+				if err != nil {
+					conn.Write([]byte{0x05, 0x05, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				defer target.Close()
+				conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 127, 0, 0, 1, 0, 0})
+				go io.Copy(target, conn)
+				io.Copy(conn, target)
+			}()
+		}
+	}()
+	return l
+}
+
+func TestSOCKS5(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "tunnelled")
+	}))
+	defer s.Close()
+	_, port, _ := net.SplitHostPort(s.Listener.Addr().String())
+	types := make(chan byte, 10)
+	l := socksServer(t, types)
+	defer l.Close()
+
+	cases := map[string]byte{"socks5": 0x01, "socks5h": 0x03}
+	for scheme, atyp := range cases {
+		r := NewURL("http://localhost:" + port)
+		r.SetProxyURL(scheme + "://user:secret@" + l.Addr().String())
+		if body, err := r.Text(); err != nil || body != "tunnelled" {
+			t.Fatalf("unexpected %s response: %s (%v)", scheme, body, err)
+		}
+		if v := <-types; v != atyp {
+			t.Fatalf("unexpected %s address type: %d", scheme, v)
+		}
+	}
+
+	r := NewURL("http://app.test:" + port)
+	r.SetProxyURL("socks5://user:secret@" + l.Addr().String())
+	r.SetResolve("app.test", "127.0.0.1")
+	if body, err := r.Text(); err != nil || body != "tunnelled" {
+		t.Fatalf("unexpected socks5 response of resolved host: %s (%v)", body, err)
+	}
+	<-types
+	r = NewURL("http://app.test:" + port)
+	r.SetProxyURL("socks5://user:secret@" + l.Addr().String())
+	r.SetResolver(func(ctx context.Context, host string) ([]string, error) {
+		return []string{"::1", "127.0.0.1"}, nil
+	})
+	if body, err := r.Text(); err != nil || body != "tunnelled" {
+		t.Fatalf("unexpected socks5 response of custom resolver: %s (%v)", body, err)
+	}
+	if v := <-types; v != 0x01 {
+		t.Fatalf("unexpected address type of custom resolver: %d", v)
+	}
+
+	r = NewURL("http://localhost:" + port)
+	r.SetProxyURL("socks5://user:wrong@" + l.Addr().String())
+	if err := r.Send(); err == nil {
+		t.Fatalf("refused authentication ignored")
+	}
+	r.SetNoProxy("localhost")
+	if err := r.Send(); err != nil {
+		t.Fatalf("proxy not bypassed: %v", err)
+	}
+}
+
+func TestProxyConnect(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "secure")
+	}))
+	defer s.Close()
+
+	var mu sync.Mutex
+	var seen http.Header
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = r.Header.Clone()
+		mu.Unlock()
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer target.Close()
+		w.WriteHeader(http.StatusOK)
+		conn, buf, _ := w.(http.Hijacker).Hijack()
+		defer conn.Close()
+		go io.Copy(target, buf)
+		io.Copy(conn, target)
+	}))
+	defer proxy.Close()
+
+	r := NewURL(s.URL)
+	r.AppendRootCA(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw}))
+	r.SetProxyURL("http://user:secret@" + proxy.Listener.Addr().String())
+	r.SetProxyHeader("X-Tenant", 42)
+	if body, err := r.Text(); err != nil || body != "secure" {
+		t.Fatalf("unexpected tunnelled response: %s (%v)", body, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if seen.Get("X-Tenant") != "42" || seen.Get("Proxy-Authorization") == "" {
+		t.Fatalf("unexpected CONNECT headers: %v", seen)
+	}
+}
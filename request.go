@@ -25,12 +25,19 @@ const DefaultAgent = "inno-go-http-client/2"
 // containing everything to prepare and download a HTTP request.
 // Should be setup by either [New] or [NewURL]
 // and then altered and executed by its methods.
+//
+// [Clone] copies pointers such as [Auth], [Cache] and [Coalesce],
+// so each is typically attached to a base Request once
+// and shared by all of its clones.
 type Request struct {
 	Error error // Setup exceptions postponed until [Send].
 
 	// Shared [http.Client] with common transportation details
 	// like cookies and timeouts.
 	*http.Client
+	// Settings of a new Client.Transport applied by [Send],
+	// as altered by setters like [SetProxyURL].
+	TransportConfig *TransportConfig
 	// Current [http.Request] including URL and Headers.
 	// Typically modified from a base Request.
 	*http.Request
@@ -41,6 +48,24 @@ type Request struct {
 	DoRetry func(*Request, error) error
 	Attempt int // Do() counter in [Send]
 	Tries   int // retry Do() if more than 1
+
+	Auth Authenticator // optionally applied to each attempt
+
+	Cache    *Cache // optionally shared storage of earlier responses
+	CacheHit bool   // Response served by [Cache] in [Send]
+	Stale    bool   // cached Response served beyond its freshness
+
+	Conditional bool // report 304 Not Modified as [ErrNotModified]
+
+	Coalesce *Coalescer // optionally shared by concurrent requests
+
+	MaxBodySize int64     // refuse to read larger responses if positive
+	Checksum    *Checksum // verify response bodies if set
+
+	// Optional hook to report transfer of request and response bodies,
+	// called at most once every ProgressInterval.
+	OnProgress       func(Progress)
+	ProgressInterval time.Duration
 }
 
 // Initialise a new [Request] with a default user agent.
@@ -60,17 +85,33 @@ func New() (r *Request) {
 // Create a new [Request] initialised with an URL.
 // This can either be a complete link ready to be downloaded,
 // or a root path to be extended by endpoints and parameters.
+// Parse errors are kept in [Error] to be reported by [Send].
 func NewURL(ref string) (r *Request) {
 	r = New()
-	_ = r.AddURL(ref) // invalid results reported by Client.Do()
+	if err := r.AddURL(ref); err != nil {
+		r.Error = err // postponed until Send()
+	}
 	return
 }
 
+// Like [NewURL] but panics if the URL cannot be parsed,
+// for static configuration known to be valid.
+func MustNewURL(ref string) *Request {
+	r := NewURL(ref)
+	if r.Error != nil {
+		panic(r.Error)
+	}
+	return r
+}
+
 // Clone a Request with its URL replaced or appended
 // by [AddURL]ing the given URI fragment.
+// Parse errors are kept in [Error] to be reported by [Send].
 func (r *Request) NewURL(ref string) (d *Request) {
 	d = r.Clone()
-	_ = d.AddURL(ref) // keep ignoring parse errors until Send()
+	if err := d.AddURL(ref); err != nil {
+		d.Error = err // postponed until Send()
+	}
 	return
 }
 
@@ -93,9 +134,23 @@ func (r *Request) Clone() *Request {
 // Send the prepared HTTP request, possibly retrying on server errors.
 // Saves a [Response] of query results, but does not download contents yet,
 // expecting manual intervention such as checking [StatusCode].
+// If a [Cache] is set, a stored response may be given instead,
+// and identical requests in progress are shared by any [Coalesce].
 func (r *Request) Send() error {
 	r.Response = nil
 	r.Attempt = 0
+	r.CacheHit = false
+	r.Stale = false
+	if r.Coalesce != nil {
+		return r.Coalesce.send(r)
+	}
+	return r.send()
+}
+
+func (r *Request) send() error {
+	if r.Cache != nil {
+		return r.Cache.send(r)
+	}
 	return r.Resend()
 }
 
@@ -104,9 +159,21 @@ func (r *Request) Resend() (err error) {
 		return
 	}
 
-	delay := time.Second
+	var delay backoff
+	renewed := false
 	for r.Attempt++; ; r.Attempt++ {
-		r.Response, err = r.Client.Do(r.Request)
+		var req *http.Request
+		if req, err = r.outgoing(); err != nil {
+			return
+		}
+		r.Response, err = r.client().Do(req)
+		if err == nil && r.StatusCode == http.StatusUnauthorized &&
+			r.Auth != nil && !renewed && r.replayable() && r.Auth.Challenge(r.Response) {
+			r.Response.Body.Close()
+			renewed = true
+			r.Attempt-- // repeated immediately
+			continue
+		}
 		if r.Attempt >= r.Tries {
 			break
 		}
@@ -118,8 +185,59 @@ func (r *Request) Resend() (err error) {
 		if err == nil {
 			break
 		}
-		time.Sleep(delay)
-		delay *= 2 // increase exponentially
+		delay.wait()
+	}
+	return
+}
+
+// Delay between repeated attempts,
+// starting at a second and doubling each time.
+type backoff time.Duration
+
+func (b *backoff) wait() {
+	if *b == 0 {
+		*b = backoff(time.Second)
+	}
+	time.Sleep(time.Duration(*b))
+	*b *= 2 // increase exponentially
+}
+
+// Whether the request body can be sent again.
+func (r *Request) replayable() bool {
+	return r.Request.Body == nil || r.Request.Body == http.NoBody || r.Request.GetBody != nil
+}
+
+// Prepare the [http.Request] of a single attempt,
+// with a fresh copy of any body provided by [Post]
+// and authenticated by any [Auth].
+func (r *Request) outgoing() (req *http.Request, err error) {
+	req = r.Request
+	if req.GetBody == nil && (req.Body == nil || r.OnProgress == nil) && r.Auth == nil {
+		return // nothing to replace
+	}
+	req = req.WithContext(req.Context()) // shallow copy
+	if r.Auth != nil {
+		req.Header = req.Header.Clone()
+		if auth, ok := r.Auth.(ClientAuthenticator); ok {
+			err = auth.AuthenticateWith(r.client(), req)
+		} else {
+			err = r.Auth.Authenticate(req)
+		}
+		if err != nil {
+			return
+		}
+	}
+	if req.GetBody != nil {
+		if req.Body, err = req.GetBody(); err != nil {
+			return
+		}
+	}
+	if r.OnProgress != nil && req.Body != nil {
+		total := req.ContentLength
+		if total == 0 && r.Request.GetBody == nil {
+			total = -1 // unknown
+		}
+		req.Body = r.progressReader(req.Body, total, true)
 	}
 	return
 }
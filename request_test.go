@@ -122,3 +122,70 @@ func TestParseQuery(t *testing.T) {
 		t.Fatalf("unexpected url results: %s", v)
 	}
 }
+
+func TestParseAddPath(t *testing.T) {
+	r := NewURL("https://localhost/api/?limit=1")
+	if err := r.AddPath("users", "a/b?#&c", 42); err != nil {
+		t.Fatalf("could not add path segments: %v", err)
+	}
+	expect := "https://localhost/api/users/a%2Fb%3F%23&c/42?limit=1"
+	if v := r.Request.URL.String(); v != expect {
+		t.Fatalf("unexpected url after path segments: %s", v)
+	}
+	if v := r.Request.URL.Path; v != "/api/users/a/b?#&c/42" {
+		t.Fatalf("unexpected decoded path: %s", v)
+	}
+
+	r.AddURL("orders")
+	expect = "https://localhost/api/users/a%2Fb%3F%23&c/42/orders?limit=1"
+	if v := r.Request.URL.String(); v != expect {
+		t.Fatalf("escaped segments altered by added path: %s", v)
+	}
+
+	for _, segment := range []any{"..", ".", "", nil} {
+		if err := r.AddPath("ok", segment); err == nil {
+			t.Fatalf("accepted path segment %#v: %s", segment, r.Request.URL)
+		}
+	}
+	if v := r.Request.URL.String(); v != expect {
+		t.Fatalf("url altered by refused segments: %s", v)
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	r := NewURL("/?page=1&limit=10&sig=a%2bb&tag=x&tag=y&empty")
+	if v, found := r.GetParam("sig"); !found || v != "a+b" {
+		t.Fatalf("unexpected parameter value: %q", v)
+	}
+	if v, found := r.GetParam("empty"); !found || v != "" {
+		t.Fatalf("unexpected empty parameter value: %q", v)
+	}
+	if _, found := r.GetParam("missing"); found {
+		t.Fatalf("unexpected missing parameter")
+	}
+	if _, found := NewURL("/").GetParam(""); found {
+		t.Fatalf("unexpected parameter without query")
+	}
+
+	r.SetParam("page", 2)
+	r.SetParam("tag", "a&b")
+	r.SetParam("new", nil)
+	expect := "page=2&limit=10&sig=a%2bb&tag=a%26b&empty&new"
+	if v := r.Request.URL.RawQuery; v != expect {
+		t.Fatalf("unexpected parameters after set: %s", v)
+	}
+
+	r.DelParam("limit")
+	r.DelParam("new")
+	r.DelParam("missing")
+	expect = "page=2&sig=a%2bb&tag=a%26b&empty"
+	if v := r.Request.URL.RawQuery; v != expect {
+		t.Fatalf("unexpected parameters after delete: %s", v)
+	}
+
+	r.AddURL("?")
+	r.SetParam("first", 1)
+	if v := r.Request.URL.RawQuery; v != "first=1" {
+		t.Fatalf("unexpected parameters set after reset: %s", v)
+	}
+}
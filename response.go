@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"strings"
 	"unicode/utf8"
@@ -18,6 +19,23 @@ func (r *Request) Success() bool {
 	return r.StatusCode >= 200 && r.StatusCode < 300
 }
 
+// Whether a conditional request was answered by 304 Not Modified,
+// indicating that earlier received contents are still current.
+func (r *Request) NotModified() bool {
+	return r.StatusCode == http.StatusNotModified
+}
+
+// Error given by [Receive] (or any dependent result method)
+// instead of a [StatusError] for a 304 Not Modified response
+// if [Conditional] is set.
+//
+//	r.SetIfNoneMatch(etag)
+//	err := r.Json(&data)
+//	if err == httpclient.ErrNotModified {
+//		return // keep previous data
+//	}
+var ErrNotModified = fmt.Errorf("not modified")
+
 // Error type given by [Receive] (or any dependent result method)
 // in case of an un[Success]ful [Status] response.
 type StatusError struct {
@@ -39,22 +57,97 @@ func (r *Request) Receive() (err error) {
 			panic("missing response")
 		}
 	}
-	if !r.Success() {
+	if r.Conditional && r.NotModified() {
+		err = ErrNotModified
+	} else if !r.Success() {
 		err = &StatusError{r.Response.StatusCode, r.Response.Status}
 		if r.Request != nil && r.Request.URL != nil {
-			err = &url.Error{r.Request.Method, r.Request.URL.String(), err}
+			err = &url.Error{Op: r.Request.Method, URL: r.Request.URL.String(), Err: err}
 		}
 	}
 	return
 }
 
+// Error type given by [Bytes] (or any dependent result method)
+// if a response body exceeds the configured [MaxBodySize].
+// Matches [ErrBodyTooLarge] for errors.Is comparisons.
+type BodySizeError struct {
+	Limit         int64
+	ContentLength int64 // declared size, or -1 if unknown
+}
+
+func (e *BodySizeError) Error() string {
+	if e.ContentLength < 0 {
+		return fmt.Sprintf("response body exceeds limit of %d bytes", e.Limit)
+	}
+	return fmt.Sprintf("response body of %d bytes exceeds limit of %d", e.ContentLength, e.Limit)
+}
+
+func (e *BodySizeError) Unwrap() error {
+	return ErrBodyTooLarge
+}
+
+// Generic error matched by any [BodySizeError].
+var ErrBodyTooLarge = fmt.Errorf("response body too large")
+
+// Reader of at most Limit bytes, failing on any excess data.
+type limitedBody struct {
+	io.ReadCloser
+	BodySizeError
+	read int64
+}
+
+func (b *limitedBody) Read(p []byte) (n int, err error) {
+	if b.read > b.Limit {
+		return 0, &b.BodySizeError // already exceeded
+	}
+	if max := b.Limit + 1 - b.read; int64(len(p)) > max {
+		p = p[:max] // probe a single byte beyond the limit
+	}
+	n, err = b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if b.read > b.Limit {
+		n -= int(b.read - b.Limit)
+		err = &b.BodySizeError
+	}
+	return
+}
+
+// Response body to be read by any result method,
+// limited to [MaxBodySize], verifying any [Checksum]
+// and reporting [OnProgress] if set.
+func (r *Request) body() (io.ReadCloser, error) {
+	body := r.Response.Body
+	switch body.(type) {
+	case *limitedBody, *hashReader, *progressReader:
+		return body, nil // already prepared
+	}
+	size := r.Response.ContentLength
+	if r.MaxBodySize > 0 {
+		if size > r.MaxBodySize {
+			// fail early without reading anything
+			return nil, &BodySizeError{r.MaxBodySize, size}
+		}
+		body = &limitedBody{body, BodySizeError{r.MaxBodySize, size}, 0}
+	}
+	body = r.hashReader(body)
+	if r.OnProgress != nil {
+		body = r.progressReader(body, size, false)
+	}
+	r.Response.Body = body
+	return body, nil
+}
+
 func (r *Request) Bytes() (out []byte, err error) {
 	err = r.Receive()
 	if r.Response == nil {
 		return
 	}
 	defer r.Response.Body.Close()
-	out, ioerr := io.ReadAll(r.Response.Body)
+	body, ioerr := r.body()
+	if ioerr == nil {
+		out, ioerr = io.ReadAll(body)
+	}
 	if err == nil {
 		err = ioerr
 	}
@@ -95,6 +188,9 @@ var ErrBodyEmpty = fmt.Errorf("empty body")
 func (r *Request) Json(serial any) error {
 	body, err := r.Text()
 	if len(body) == 0 {
+		if err == ErrNotModified || errors.Is(err, ErrBodyTooLarge) {
+			return err // nothing to read
+		}
 		return ErrBodyEmpty
 	}
 	if body[0] == '<' {
@@ -120,7 +216,12 @@ func (r *Request) Xml(serial any) error {
 	if r.Response.ContentLength == 0 {
 		return ErrBodyEmpty
 	}
-	d := xml.NewDecoder(r.Response.Body)
+	body, err := r.body()
+	if err != nil {
+		r.Response.Body.Close()
+		return err
+	}
+	d := xml.NewDecoder(body)
 	d.CharsetReader = func(xmlenc string, in io.Reader) (out io.Reader, err error) {
 		// support for some common non-utf8 encoding declarations
 		switch strings.ToLower(xmlenc) {
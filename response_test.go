@@ -10,6 +10,7 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const sampleText = "Eĥoŝanĝº ĉiĵaŭde" // valid unicode
@@ -216,3 +217,120 @@ func TestRequestXmlEmpty(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestRequestBodySize(t *testing.T) {
+	r := httpResult(200, sampleJson)
+	r.SetMaxBodySize(int64(len(sampleJson)))
+	if _, err := r.Text(); err != nil {
+		t.Fatalf("unexpected error at exact limit: %v", err)
+	}
+
+	r = httpResult(200, sampleJson)
+	r.SetMaxBodySize(10)
+	var res HttpbinEcho
+	err := r.Json(&res)
+	var sizeerr *BodySizeError
+	if !errors.As(err, &sizeerr) {
+		t.Fatalf("unexpected error type: %T", err)
+	}
+	if sizeerr.Limit != 10 || sizeerr.ContentLength != int64(len(sampleJson)) {
+		t.Fatalf("unexpected error details: %v", sizeerr)
+	}
+
+	r = httpResult(200, sampleJson)
+	r.Response.ContentLength = -1 // undeclared
+	r.SetMaxBodySize(10)
+	body, err := r.Bytes()
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Fatalf("unexpected error reading beyond limit: %v", err)
+	}
+	if len(body) != 10 {
+		t.Fatalf("unexpected partial body: %q", body)
+	}
+	if body, err = r.Bytes(); !errors.Is(err, ErrBodyTooLarge) || len(body) != 0 {
+		t.Fatalf("unexpected reread beyond limit: %q (%v)", body, err)
+	}
+
+	r = httpResult(200, sampleJson)
+	r.Response.ContentLength = -1
+	r.SetMaxBodySize(10)
+	if err = r.Json(&res); !errors.Is(err, ErrBodyTooLarge) {
+		t.Fatalf("unexpected json error beyond limit: %v", err)
+	}
+	if preview := r.Preview(); preview != "" {
+		t.Fatalf("unexpected preview beyond limit: %q", preview)
+	}
+}
+
+func TestRequestChecksum(t *testing.T) {
+	const sampleSha256 = "wvfjHiR/GGgqcJWIsy/JqCRanNzrtDVio76cUqXqD8E="
+	r := httpResult(200, sampleText)
+	r.Response.Header.Set("Repr-Digest", "sha-256=:"+sampleSha256+":")
+	if err := r.SetChecksum("", ""); err != nil {
+		t.Fatalf("could not enable checksum: %v", err)
+	}
+	if _, err := r.Text(); err != nil {
+		t.Fatalf("unexpected error verifying digest header: %v", err)
+	}
+
+	r = httpResult(200, sampleData)
+	r.Response.Header.Set("Digest", "SHA-256="+sampleSha256)
+	r.SetChecksum("", "")
+	_, err := r.Bytes()
+	var sumerr *ChecksumError
+	if !errors.As(err, &sumerr) {
+		t.Fatalf("unexpected error type: %T", err)
+	}
+	if sumerr.Algorithm != "sha256" || len(sumerr.Actual) != 32 {
+		t.Fatalf("unexpected error details: %v", sumerr)
+	}
+
+	r = httpResult(200, sampleText)
+	if err := r.SetChecksum("SHA-256", "00"); err != nil {
+		t.Fatalf("could not set checksum: %v", err)
+	}
+	if _, err := r.Text(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("unexpected error for wrong checksum: %v", err)
+	}
+	if err := r.SetChecksum("crc32", ""); err == nil {
+		t.Fatalf("unsupported algorithm accepted")
+	}
+}
+
+func TestRequestNotModified(t *testing.T) {
+	r := httpResult(304, "")
+	err := r.Receive()
+	var e *StatusError
+	if !errors.As(err, &e) || e.Code != 304 {
+		t.Fatalf("unexpected unconditional error: %v", err)
+	}
+
+	r.Request = New().Request
+	r.SetIfModifiedSince(time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC))
+	if v := r.Request.Header.Get("If-Modified-Since"); v != "Sat, 03 Feb 2001 04:05:06 GMT" {
+		t.Fatalf("unexpected condition header: %s", v)
+	}
+	r.SetIfNoneMatch("v1")
+	if v := r.Request.Header.Get("If-None-Match"); v != `"v1"` {
+		t.Fatalf("unexpected entity tag header: %s", v)
+	}
+	if !r.NotModified() {
+		t.Fatalf("unrecognised status: %s", r.Status)
+	}
+	var res HttpbinEcho
+	if err := r.Json(&res); err != ErrNotModified {
+		t.Fatalf("unexpected conditional error: %v", err)
+	}
+
+	r.SetIfNoneMatch("")
+	if !r.Conditional {
+		t.Fatalf("conditional reset while modification time remains")
+	}
+	r.SetIfModifiedSince(time.Time{})
+	if r.Conditional {
+		t.Fatalf("conditional kept without any condition")
+	}
+	if err := r.Receive(); !errors.As(err, &e) || e.Code != 304 {
+		t.Fatalf("unexpected unconditional error: %v", err)
+	}
+}
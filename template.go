@@ -0,0 +1,375 @@
+package httpclient
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Create a new [Request] initialised with an expanded URI template,
+// like [NewURL] after [ExpandTemplate].
+// Expansion errors are kept in [Error] to be reported by [Send].
+func NewTemplate(template string, vars any) (r *Request) {
+	r = New()
+	if err := r.AddTemplate(template, vars); err != nil {
+		r.Error = err // postponed until Send()
+	}
+	return
+}
+
+// Clone a Request with its URL altered by [AddTemplate].
+// Expansion errors are kept in [Error] to be reported by [Send].
+func (r *Request) NewTemplate(template string, vars any) (d *Request) {
+	d = r.Clone()
+	if err := d.AddTemplate(template, vars); err != nil {
+		d.Error = err // postponed until Send()
+	}
+	return
+}
+
+// Alter the request URL by [AddURL] of an [ExpandTemplate] result,
+// so any expanded parts replace or append to the current location.
+//
+//	r.AddTemplate("/users/{id}/orders{?status,limit}", map[string]any{
+//		"id":     "a/b",
+//		"status": []string{"open", "paid"},
+//	}) // "/users/a%2Fb/orders?status=open,paid"
+func (r *Request) AddTemplate(template string, vars any) error {
+	ref, err := ExpandTemplate(template, vars)
+	if err != nil {
+		return err
+	}
+	return r.AddURL(ref)
+}
+
+// Expand a URI template of RFC 6570 (up to level 4) by variables
+// from either a map with string keys, or a struct with `uri:"name"` tags
+// or otherwise its field names.
+//
+// Values are stringified like [SetHeader],
+// except for slices (lists) and maps (associative arrays).
+// Missing and nil values are undefined and omitted.
+// All operators (+ # . / ; ? &) and modifiers (:prefix and *explode)
+// are supported:
+//
+//	{var}        // "value%20escaped"
+//	{+path}      // "/reserved/characters"
+//	{/list*}     // "/red/green/blue"
+//	{?keys*}     // "?semi=%3B&dot=."
+//	{;var:3}     // ";var=val"
+func ExpandTemplate(template string, vars any) (string, error) {
+	lookup, err := templateVars(vars)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	for {
+		open := strings.IndexByte(template, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(template[open:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated template expression at %d", open)
+		}
+		out.WriteString(encodeLiteral(template[:open]))
+		if err = expandExpression(&out, template[open+1:open+end], lookup); err != nil {
+			return "", err
+		}
+		template = template[open+end+1:]
+	}
+	if strings.IndexByte(template, '}') >= 0 {
+		return "", fmt.Errorf("unexpected template expression end")
+	}
+	out.WriteString(encodeLiteral(template))
+	return out.String(), nil
+}
+
+// Expansion behaviour of each expression operator (RFC 6570 appendix A).
+type templateOperator struct {
+	first    string // prefix if any value is defined
+	sep      string // separator between values
+	named    bool   // include variable names
+	ifemp    string // suffix of names with empty values
+	reserved bool   // allow reserved characters unencoded
+}
+
+var templateOperators = map[byte]templateOperator{
+	'+': {"", ",", false, "", true},
+	'#': {"#", ",", false, "", true},
+	'.': {".", ".", false, "", false},
+	'/': {"/", "/", false, "", false},
+	';': {";", ";", true, "", false},
+	'?': {"?", "&", true, "=", false},
+	'&': {"&", "&", true, "=", false},
+}
+
+func expandExpression(out *strings.Builder, expr string, lookup func(string) any) error {
+	if expr == "" {
+		return fmt.Errorf("empty template expression")
+	}
+	op, found := templateOperators[expr[0]]
+	if found {
+		expr = expr[1:]
+	} else {
+		op = templateOperator{sep: ","} // simple string expansion
+	}
+	if expr == "" {
+		return fmt.Errorf("empty template expression")
+	}
+
+	first := true
+	for _, spec := range strings.Split(expr, ",") {
+		name, explode := strings.CutSuffix(spec, "*")
+		prefix := 0
+		if cut := strings.IndexByte(name, ':'); cut >= 0 {
+			n, err := strconv.Atoi(name[cut+1:])
+			if err != nil || n <= 0 || n >= 10000 || explode {
+				return fmt.Errorf("invalid template prefix in %q", spec)
+			}
+			name, prefix = name[:cut], n
+		}
+		if !validVarname(name) {
+			return fmt.Errorf("invalid template variable name in %q", spec)
+		}
+
+		value := templateValue(lookup(name))
+		if value == nil {
+			continue // undefined
+		}
+		if first {
+			out.WriteString(op.first)
+			first = false
+		} else {
+			out.WriteString(op.sep)
+		}
+
+		switch v := value.(type) {
+		case string:
+			if op.named {
+				out.WriteString(encodeValue(name, true))
+				if v == "" {
+					out.WriteString(op.ifemp)
+					continue
+				}
+				out.WriteByte('=')
+			}
+			if prefix > 0 {
+				if runes := []rune(v); len(runes) > prefix {
+					v = string(runes[:prefix])
+				}
+			}
+			out.WriteString(encodeValue(v, op.reserved))
+
+		case []string:
+			if prefix > 0 {
+				return fmt.Errorf("prefix of composite template variable %q", name)
+			}
+			sep := ","
+			if explode {
+				sep = op.sep
+			} else if op.named {
+				out.WriteString(encodeValue(name, true) + "=")
+			}
+			for i, item := range v {
+				if i > 0 {
+					out.WriteString(sep)
+				}
+				if explode && op.named {
+					out.WriteString(encodeValue(name, true))
+					if item == "" {
+						out.WriteString(op.ifemp)
+						continue
+					}
+					out.WriteByte('=')
+				}
+				out.WriteString(encodeValue(item, op.reserved))
+			}
+
+		case [][2]string:
+			if prefix > 0 {
+				return fmt.Errorf("prefix of composite template variable %q", name)
+			}
+			if !explode && op.named {
+				out.WriteString(encodeValue(name, true) + "=")
+			}
+			for i, pair := range v {
+				if i > 0 {
+					if explode {
+						out.WriteString(op.sep)
+					} else {
+						out.WriteByte(',')
+					}
+				}
+				out.WriteString(encodeValue(pair[0], op.reserved))
+				if explode {
+					if pair[1] == "" && op.named {
+						out.WriteString(op.ifemp)
+						continue
+					}
+					out.WriteByte('=')
+				} else {
+					out.WriteByte(',')
+				}
+				out.WriteString(encodeValue(pair[1], op.reserved))
+			}
+		}
+	}
+	return nil
+}
+
+// Normalise a variable to either a string, a list of strings,
+// or sorted key/value pairs; or nil if undefined.
+func templateValue(v any) any {
+	if v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if _, ok := v.(fmt.Stringer); ok {
+		return fmt.Sprintf("%v", v)
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			return string(rv.Bytes())
+		}
+		if rv.Len() == 0 {
+			return nil // empty lists are undefined
+		}
+		list := make([]string, rv.Len())
+		for i := range list {
+			list[i] = fmt.Sprintf("%v", rv.Index(i).Interface())
+		}
+		return list
+	case reflect.Map:
+		if rv.Len() == 0 {
+			return nil
+		}
+		pairs := make([][2]string, 0, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			pairs = append(pairs, [2]string{
+				fmt.Sprintf("%v", iter.Key().Interface()),
+				fmt.Sprintf("%v", iter.Value().Interface()),
+			})
+		}
+		sort.Slice(pairs, func(i, j int) bool {
+			return pairs[i][0] < pairs[j][0]
+		})
+		return pairs
+	}
+	return fmt.Sprintf("%v", rv.Interface()) // stringify any
+}
+
+// Provide variable lookup of a map or struct.
+func templateVars(vars any) (func(string) any, error) {
+	if vars == nil {
+		return func(string) any { return nil }, nil
+	}
+	if m, ok := vars.(map[string]any); ok {
+		return func(name string) any { return m[name] }, nil
+	}
+
+	rv := reflect.ValueOf(vars)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		return func(name string) any {
+			v := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+			if !v.IsValid() {
+				return nil
+			}
+			return v.Interface()
+		}, nil
+	case reflect.Struct:
+		fields := make(map[string]int)
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := f.Name
+			if tag := f.Tag.Get("uri"); tag == "-" {
+				continue
+			} else if tag != "" {
+				name = tag
+			}
+			fields[name] = i
+		}
+		return func(name string) any {
+			i, found := fields[name]
+			if !found {
+				return nil
+			}
+			return rv.Field(i).Interface()
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported template variables of type %T", vars)
+}
+
+const templateReserved = ":/?#[]@!$&'()*+,;="
+
+// Percent-encode all but unreserved characters,
+// or also allow reserved characters and existing %-escapes.
+func encodeValue(s string, reserved bool) string {
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~':
+			out.WriteByte(c)
+		case reserved && strings.IndexByte(templateReserved, c) >= 0:
+			out.WriteByte(c)
+		case reserved && c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			out.WriteString(s[i : i+3])
+			i += 2
+		default:
+			fmt.Fprintf(&out, "%%%02X", c)
+		}
+	}
+	return out.String()
+}
+
+// Literal template parts are copied with only invalid characters escaped.
+func encodeLiteral(s string) string {
+	return encodeValue(s, true)
+}
+
+// Variable names are limited to alphanumerics, underscores,
+// dots and %-escapes.
+func validVarname(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '_', c == '.':
+		case c == '%' && i+2 < len(name) && isHex(name[i+1]) && isHex(name[i+2]):
+			i += 2
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
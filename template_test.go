@@ -0,0 +1,96 @@
+package httpclient
+
+import (
+	"testing"
+)
+
+func TestTemplateExpand(t *testing.T) {
+	vars := map[string]any{
+		"var":   "value",
+		"hello": "Hello World!",
+		"path":  "/foo/bar",
+		"list":  []string{"red", "green", "blue"},
+		"keys":  map[string]string{"semi": ";", "dot": ".", "comma": ","},
+		"empty": "",
+		"x":     1024,
+		"y":     768,
+		"undef": nil,
+	}
+	// examples of RFC 6570 section 3.2 in sorted key order
+	cases := map[string]string{
+		"{var}":             "value",
+		"{hello}":           "Hello%20World%21",
+		"{x,hello,y}":       "1024,Hello%20World%21,768",
+		"{undef}":           "",
+		"{var:3}":           "val",
+		"{var:30}":          "value",
+		"{list}":            "red,green,blue",
+		"{list*}":           "red,green,blue",
+		"{keys}":            "comma,%2C,dot,.,semi,%3B",
+		"{keys*}":           "comma=%2C,dot=.,semi=%3B",
+		"{+path}/here":      "/foo/bar/here",
+		"{+path:6}/here":    "/foo/b/here",
+		"{+path,x}/here":    "/foo/bar,1024/here",
+		"{+keys*}":          "comma=,,dot=.,semi=;",
+		"{#x,hello,y}":      "#1024,Hello%20World!,768",
+		"{#path:6}/here":    "#/foo/b/here",
+		"X{.var:3}":         "X.val",
+		"X{.list*}":         "X.red.green.blue",
+		"X{.keys*}":         "X.comma=%2C.dot=..semi=%3B",
+		"{/var:1,var}":      "/v/value",
+		"{/list*,path:4}":   "/red/green/blue/%2Ffoo",
+		"{;x,y,empty}":      ";x=1024;y=768;empty",
+		"{;hello:5}":        ";hello=Hello",
+		"{;list}":           ";list=red,green,blue",
+		"{;list*}":          ";list=red;list=green;list=blue",
+		"{;keys}":           ";keys=comma,%2C,dot,.,semi,%3B",
+		"{?x,y,empty}":      "?x=1024&y=768&empty=",
+		"{?x,undef}":        "?x=1024",
+		"{?list*}":          "?list=red&list=green&list=blue",
+		"{?keys*}":          "?comma=%2C&dot=.&semi=%3B",
+		"{&var:3}":          "&var=val",
+		"literal%20{var}%z": "literal%20value%25z",
+	}
+	for tmpl, expect := range cases {
+		v, err := ExpandTemplate(tmpl, vars)
+		if err != nil {
+			t.Fatalf("could not expand %s: %v", tmpl, err)
+		}
+		if v != expect {
+			t.Fatalf("unexpected expansion of %s: %s", tmpl, v)
+		}
+	}
+
+	for _, tmpl := range []string{"{var", "var}", "{}", "{list*:3}", "{var:0}"} {
+		if v, err := ExpandTemplate(tmpl, vars); err == nil {
+			t.Fatalf("invalid template %s expanded to %s", tmpl, v)
+		}
+	}
+}
+
+func TestTemplateRequest(t *testing.T) {
+	c := NewTemplate("https://localhost/api{/version}?key={key}", struct {
+		Version string `uri:"version"`
+		Key     string `uri:"key"`
+	}{"v1", "a&b"})
+	if v := c.Request.URL.String(); v != "https://localhost/api/v1?key=a%26b" {
+		t.Fatalf("unexpected initial url: %s", v)
+	}
+
+	r := c.NewTemplate("users/{id}/orders{?status,limit}", map[string]any{
+		"id":     "a/b?",
+		"status": []string{"open", "paid"},
+	})
+	expect := "https://localhost/api/v1/users/a%2Fb%3F/orders?status=open,paid"
+	if v := r.Request.URL.String(); v != expect {
+		t.Fatalf("unexpected endpoint url: %s", v)
+	}
+	if v := r.Request.URL.Path; v != "/api/v1/users/a/b?/orders" {
+		t.Fatalf("unexpected decoded path: %s", v)
+	}
+
+	r.AddTemplate("{&limit}", map[string]int{"limit": 10})
+	if v := r.Request.URL.RawQuery; v != "status=open,paid&limit=10" {
+		t.Fatalf("unexpected appended parameters: %s", v)
+	}
+}
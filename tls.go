@@ -0,0 +1,125 @@
+package httpclient
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Present a client certificate for mutual TLS,
+// read from PEM encoded certificate and private key files.
+func (r *Request) SetClientCertificate(certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	return r.setTLS(func(c *tls.Config) {
+		c.Certificates = []tls.Certificate{cert}
+	})
+}
+
+// Present a client certificate for mutual TLS from PEM encoded data,
+// like [SetClientCertificate].
+func (r *Request) SetClientCertificatePEM(cert, key []byte) error {
+	pair, err := tls.X509KeyPair(cert, key)
+	if err != nil {
+		return err
+	}
+	return r.setTLS(func(c *tls.Config) {
+		c.Certificates = []tls.Certificate{pair}
+	})
+}
+
+// Replace the certificate authorities trusted to verify servers,
+// or restore the system roots if nil.
+func (r *Request) SetRootCAs(pool *x509.CertPool) error {
+	return r.setTLS(func(c *tls.Config) {
+		c.RootCAs = pool
+	})
+}
+
+// Trust additional certificate authorities of PEM encoded data,
+// added to any previously set or otherwise system roots.
+//
+//	ca, err := os.ReadFile("/etc/ssl/private-ca.pem")
+//	err = r.AppendRootCA(ca)
+func (r *Request) AppendRootCA(pem []byte) (err error) {
+	transportErr := r.setTLS(func(c *tls.Config) {
+		pool := c.RootCAs
+		if pool == nil {
+			pool, _ = x509.SystemCertPool()
+			if pool == nil {
+				pool = x509.NewCertPool() // unavailable system roots
+			}
+		} else {
+			pool = pool.Clone()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			err = fmt.Errorf("no certificates found in PEM data")
+			return
+		}
+		c.RootCAs = pool
+	})
+	if transportErr != nil {
+		return transportErr
+	}
+	return
+}
+
+// Trust additional certificate authorities of a PEM encoded file,
+// like [AppendRootCA].
+func (r *Request) AppendRootCAFile(path string) error {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return r.AppendRootCA(pem)
+}
+
+// Refuse TLS versions older than given, such as [tls.VersionTLS13].
+func (r *Request) SetTLSMinVersion(version uint16) error {
+	return r.setTLS(func(c *tls.Config) {
+		c.MinVersion = version
+	})
+}
+
+// Override the server name sent by SNI and verified in certificates,
+// instead of the host of the request URL.
+func (r *Request) SetServerName(name string) error {
+	return r.setTLS(func(c *tls.Config) {
+		c.ServerName = name
+	})
+}
+
+// Only accept servers with a verified certificate chain containing
+// any of the given public keys, such as of an intermediate or root
+// authority that is not presented by the server itself.
+// Pinning is in addition to regular verification, and fails without it.
+// Keys are identified by the base64 SHA-256 hash of their
+// SubjectPublicKeyInfo, optionally prefixed by "sha256/":
+//
+//	openssl x509 -pubkey -noout | openssl pkey -pubin -outform der |
+//		openssl dgst -sha256 -binary | base64
+func (r *Request) SetPinnedKeys(hashes ...string) error {
+	pins := make(map[string]bool, len(hashes))
+	for _, pin := range hashes {
+		pins[strings.TrimPrefix(pin, "sha256/")] = true
+	}
+	return r.setTLS(func(c *tls.Config) {
+		c.VerifyConnection = func(cs tls.ConnectionState) error {
+			for _, chain := range cs.VerifiedChains {
+				for _, cert := range chain {
+					sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+					if pins[base64.StdEncoding.EncodeToString(sum[:])] {
+						return nil
+					}
+				}
+			}
+			return fmt.Errorf("no pinned public key presented by %s", cs.ServerName)
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package httpclient
+
+import (
+	"testing"
+
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+// Self-signed certificate and key in PEM format.
+func testCertificate(t *testing.T) (cert, key []byte) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	key = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})
+	return
+}
+
+func TestTLS(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	s := httptest.NewUnstartedServer(h)
+	s.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	s.StartTLS()
+	defer s.Close()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw})
+	sum := sha256.Sum256(s.Certificate().RawSubjectPublicKeyInfo)
+	pin := "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+
+	c := NewURL(s.URL)
+	if err := c.SetProxyURL("http://proxy.invalid"); err != nil {
+		t.Fatalf("could not set proxy: %v", err)
+	}
+	proxy := c.TransportConfig
+	if err := c.AppendRootCA(ca); err != nil {
+		t.Fatalf("could not append certificate: %v", err)
+	}
+	if proxy.TLS != nil {
+		t.Fatalf("earlier transport configuration altered")
+	}
+	if c.TransportConfig.Transport().Proxy == nil {
+		t.Fatalf("proxy lost by TLS configuration")
+	}
+	c.SetProxyURL("") // direct connection
+	c.SetTLSMinVersion(tls.VersionTLS12)
+
+	r := c.Clone()
+	if err := r.Receive(); err == nil || r.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("unexpected response without certificate: %v", err)
+	}
+	cert, key := testCertificate(t)
+	if err := c.SetClientCertificatePEM(cert, key); err != nil {
+		t.Fatalf("could not set client certificate: %v", err)
+	}
+	if err := c.Clone().Receive(); err != nil {
+		t.Fatalf("client certificate refused: %v", err)
+	}
+
+	r = c.Clone()
+	r.SetServerName("example.com") // included by the test certificate
+	if err := r.Receive(); err != nil {
+		t.Fatalf("server name not overridden: %v", err)
+	}
+	r.SetServerName("other.invalid")
+	if err := r.Send(); err == nil {
+		t.Fatalf("mismatching server name accepted")
+	}
+
+	r = c.Clone()
+	r.SetPinnedKeys("sha256/AAAA", pin)
+	if err := r.Receive(); err != nil {
+		t.Fatalf("pinned key refused: %v", err)
+	}
+	r.SetPinnedKeys("AAAA")
+	if err := r.Send(); err == nil {
+		t.Fatalf("unpinned key accepted")
+	}
+}
+
+func TestPinnedAuthority(t *testing.T) {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(caDer)
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	s.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	s.StartTLS()
+	defer s.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	sum := sha256.Sum256(ca.RawSubjectPublicKeyInfo)
+
+	r := NewURL(s.URL)
+	if err := r.SetRootCAs(pool); err != nil {
+		t.Fatalf("could not set authority: %v", err)
+	}
+	r.SetPinnedKeys(base64.StdEncoding.EncodeToString(sum[:]))
+	if err := r.Receive(); err != nil {
+		t.Fatalf("pinned authority refused: %v", err)
+	}
+
+	custom := New()
+	custom.Client.Transport = roundTripFunc(http.DefaultTransport.RoundTrip)
+	if err := custom.SetRootCAs(pool); err == nil {
+		t.Fatalf("authority set in custom transport")
+	}
+	if err := custom.SetPinnedKeys("AAAA"); err == nil {
+		t.Fatalf("pinned keys set in custom transport")
+	}
+
+	r = NewURL(s.URL)
+	r.Post(func() {}) // invalid
+	if err := r.SetRootCAs(nil); err != nil {
+		t.Fatalf("unrelated error given by setter: %v", err)
+	}
+}
@@ -0,0 +1,276 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Settings of an [http.Transport] accumulated by setters
+// such as [SetProxyURL], [AppendRootCA] and [SetDialTimeout],
+// so they can be applied in any order.
+//
+// The transport is only created by the first [Send],
+// starting from a copy of [http.DefaultTransport] (or any *http.Transport
+// set in [Client] beforehand) to keep its defaults like HTTP/2,
+// connection pooling and timeouts. It is then reused by all clones
+// until any of them alters their configuration.
+// Zero values keep the defaults.
+//
+// Setters are therefore best applied to a base [Request] only:
+// each altered clone builds a separate transport with its own connection
+// pool, which is only closed once the clone is garbage collected.
+// Setters return an error if the [Client] has a custom RoundTripper
+// instead of an *http.Transport, which is also reported by [Send].
+type TransportConfig struct {
+	Base  *http.Transport // initial settings, or DefaultTransport if nil
+	Proxy func(*http.Request) (*url.URL, error)
+	TLS   *tls.Config
+
+	SOCKS              *url.URL    // socks5 or socks5h proxy replacing Proxy
+	ProxyConnectHeader http.Header // sent to HTTP proxies by CONNECT requests
+	NoProxy            []string    // hosts connected directly, see [SetNoProxy]
+
+	// Custom function to establish connections,
+	// or a [net.Dialer] of DialTimeout and KeepAlive if nil.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+	// Path of a unix domain socket to connect to instead of any host.
+	UnixSocket string
+	// Addresses to connect to instead of resolving "host:port" or "host",
+	// see [SetResolve].
+	Resolve map[string]string
+	// Custom lookup of host addresses, like [net.Resolver.LookupHost].
+	LookupHost func(ctx context.Context, host string) ([]string, error)
+
+	DialTimeout         time.Duration
+	KeepAlive           time.Duration // interval of TCP keep-alive probes
+	TLSHandshakeTimeout time.Duration
+	IdleConnTimeout     time.Duration
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	DisableHTTP2        bool
+	DisableKeepAlives   bool // new connection for each request
+
+	built *builtTransport // shared by copies until altered
+}
+
+// Guards configurations without any transport yet.
+var builtMu sync.Mutex
+
+// Transport created once for a configuration.
+type builtTransport struct {
+	once      sync.Once
+	transport *http.Transport
+}
+
+// Configuration of the request to be altered by setters,
+// copied so other requests sharing the previous one are unaffected.
+// Custom RoundTrippers cannot be configured and are reported in [Error],
+// leaving a discarded configuration to the setter.
+func (r *Request) transportConfig() (*TransportConfig, error) {
+	c := new(TransportConfig)
+	if r.TransportConfig != nil {
+		*c = *r.TransportConfig
+		if c.TLS != nil {
+			c.TLS = c.TLS.Clone()
+		}
+		c.ProxyConnectHeader = c.ProxyConnectHeader.Clone()
+		resolve := make(map[string]string, len(c.Resolve))
+		for k, v := range c.Resolve {
+			resolve[k] = v
+		}
+		c.Resolve = resolve
+		c.NoProxy = append([]string(nil), c.NoProxy...)
+	} else {
+		switch t := r.Client.Transport.(type) {
+		case nil:
+		case *http.Transport:
+			c.Base = t
+		default:
+			err := fmt.Errorf("cannot configure custom transport %T", t)
+			r.Error = err // postponed until Send()
+			return c, err
+		}
+	}
+	c.built = new(builtTransport) // settings about to change
+	r.TransportConfig = c
+	return c, nil
+}
+
+// Alter the TLS configuration of the transport.
+func (r *Request) setTLS(configure func(*tls.Config)) error {
+	c, err := r.transportConfig()
+	if c.TLS == nil {
+		if c.Base != nil && c.Base.TLSClientConfig != nil {
+			c.TLS = c.Base.TLSClientConfig.Clone()
+		} else {
+			c.TLS = new(tls.Config)
+		}
+	}
+	configure(c.TLS)
+	return err
+}
+
+// The configured transport, created once and shared by all copies.
+// Direct changes to the configuration are therefore only effective
+// before its first use.
+func (c *TransportConfig) Transport() *http.Transport {
+	builtMu.Lock()
+	if c.built == nil {
+		c.built = new(builtTransport) // configured without setters
+	}
+	b := c.built
+	builtMu.Unlock()
+	b.once.Do(func() {
+		b.transport = c.build()
+		// no more requests once all configurations sharing it are dropped
+		runtime.SetFinalizer(b, func(b *builtTransport) {
+			b.transport.CloseIdleConnections()
+		})
+	})
+	return b.transport
+}
+
+func (c *TransportConfig) build() *http.Transport {
+	base := c.Base
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	t := base.Clone()
+	if c.Proxy != nil {
+		t.Proxy = c.Proxy
+	}
+	if c.TLS != nil {
+		t.TLSClientConfig = c.TLS.Clone()
+	}
+	if c.DialContext != nil {
+		t.DialContext = c.DialContext
+	} else if c.DialTimeout != 0 || c.KeepAlive != 0 {
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second, // like DefaultTransport
+			KeepAlive: 30 * time.Second,
+		}
+		if c.DialTimeout != 0 {
+			dialer.Timeout = c.DialTimeout
+		}
+		if c.KeepAlive != 0 {
+			dialer.KeepAlive = c.KeepAlive
+		}
+		t.DialContext = dialer.DialContext
+	}
+	dial := t.DialContext
+	if dial == nil {
+		dial = new(net.Dialer).DialContext
+	}
+	if len(c.Resolve) > 0 || c.LookupHost != nil {
+		dial = (&resolvingDialer{c.Resolve, c.LookupHost, dial}).DialContext
+		t.DialContext = dial
+	}
+	if c.UnixSocket != "" {
+		socket := c.UnixSocket
+		t.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dial(ctx, "unix", socket)
+		}
+		t.Proxy = nil
+	} else if c.SOCKS != nil {
+		t.DialContext = (&socksDialer{c.SOCKS, dial, c.NoProxy, c.Resolve, c.LookupHost}).DialContext
+		t.Proxy = nil
+	}
+	if t.Proxy != nil && len(c.NoProxy) > 0 {
+		t.Proxy = bypassProxy(t.Proxy, c.NoProxy)
+	}
+	if c.ProxyConnectHeader != nil {
+		t.ProxyConnectHeader = c.ProxyConnectHeader.Clone()
+	}
+	if c.TLSHandshakeTimeout != 0 {
+		t.TLSHandshakeTimeout = c.TLSHandshakeTimeout
+	}
+	if c.IdleConnTimeout != 0 {
+		t.IdleConnTimeout = c.IdleConnTimeout
+	}
+	if c.MaxIdleConns != 0 {
+		t.MaxIdleConns = c.MaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost != 0 {
+		t.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	}
+	if c.MaxConnsPerHost != 0 {
+		t.MaxConnsPerHost = c.MaxConnsPerHost
+	}
+	if c.DisableHTTP2 {
+		t.ForceAttemptHTTP2 = false
+		// an empty map prevents automatic HTTP/2 upgrades
+		t.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	t.DisableKeepAlives = t.DisableKeepAlives || c.DisableKeepAlives
+	return t
+}
+
+// Client to perform a single attempt,
+// with any [TransportConfig] applied.
+func (r *Request) client() *http.Client {
+	if r.TransportConfig == nil {
+		return r.Client
+	}
+	c := *r.Client
+	c.Transport = r.TransportConfig.Transport()
+	return &c
+}
+
+// Connections to addresses overriding the regular host resolution,
+// leaving the request URL (and therefore Host and TLS server name) intact.
+type resolvingDialer struct {
+	resolve map[string]string
+	lookup  func(ctx context.Context, host string) ([]string, error)
+	dial    dialFunc
+}
+
+func (d *resolvingDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if target, found := resolveOverride(d.resolve, host, port); found {
+		return d.dial(ctx, network, target)
+	}
+	if d.lookup == nil || net.ParseIP(host) != nil {
+		return d.dial(ctx, network, addr)
+	}
+
+	addrs, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	err = fmt.Errorf("no addresses found for %s", host)
+	for _, ip := range addrs {
+		var conn net.Conn
+		if conn, err = d.dial(ctx, network, net.JoinHostPort(ip, port)); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err // last failure
+}
+
+// Address configured by [SetResolve] for a host and port,
+// with the same port unless specified.
+func resolveOverride(resolve map[string]string, host, port string) (target string, found bool) {
+	host = strings.ToLower(host)
+	target, found = resolve[net.JoinHostPort(host, port)]
+	if !found {
+		target, found = resolve[host]
+	}
+	if found {
+		if _, _, err := net.SplitHostPort(target); err != nil {
+			target = net.JoinHostPort(target, port) // same port
+		}
+	}
+	return
+}
@@ -0,0 +1,184 @@
+package httpclient
+
+import (
+	"testing"
+
+	"context"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTransportConfig(t *testing.T) {
+	c := client.Clone()
+	c.SetMaxIdleConns(10, 2)
+	c.SetDialTimeout(5)
+	if err := c.SetProxyURL("http://proxy.invalid"); err != nil {
+		t.Fatalf("could not set proxy: %v", err)
+	}
+	c.SetTLSMinVersion(0)
+	c.SetHTTP2(false)
+
+	tr := c.TransportConfig.Transport()
+	if tr.MaxIdleConnsPerHost != 2 || tr.Proxy == nil || tr.TLSClientConfig == nil {
+		t.Fatalf("settings not accumulated: %+v", tr)
+	}
+	if tr.TLSNextProto == nil || tr.ForceAttemptHTTP2 {
+		t.Fatalf("http/2 not disabled")
+	}
+	if tr.IdleConnTimeout != client.Client.Transport.(*http.Transport).IdleConnTimeout {
+		t.Fatalf("base transport settings lost")
+	}
+
+	r := c.Clone()
+	if r.TransportConfig.Transport() != tr {
+		t.Fatalf("transport not shared by clones")
+	}
+	r.SetProxyURL("")
+	if r.TransportConfig.Transport() == tr || c.TransportConfig.Transport() != tr {
+		t.Fatalf("transport of clone not separated")
+	}
+	if r.TransportConfig.DialTimeout != 5*time.Second {
+		t.Fatalf("settings of clone lost")
+	}
+	d := r.NewURL("status/404")
+	if err := d.Send(); err != nil || d.StatusCode != 404 {
+		t.Fatalf("request not sent by configured transport: %v", err)
+	}
+
+	custom := New()
+	custom.Client.Transport = roundTripFunc(http.DefaultTransport.RoundTrip)
+	if err := custom.SetHTTP2(false); err == nil || custom.Error == nil {
+		t.Fatalf("custom transport configured")
+	}
+
+	invalid := NewURL("http://localhost/%zz")
+	if err := invalid.SetProxyURL("http://proxy.invalid:3128"); err != nil {
+		t.Fatalf("unrelated error given by setter: %v", err)
+	}
+}
+
+func TestTransportRelease(t *testing.T) {
+	var closed atomic.Int32
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	s.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			closed.Add(1)
+		}
+	}
+	s.Start()
+	defer s.Close()
+
+	func() {
+		r := NewURL(s.URL)
+		r.SetDialTimeout(5)
+		if err := r.Send(); err != nil {
+			t.Fatalf("could not send: %v", err)
+		}
+		r.Response.Body.Close() // idle connection kept
+	}()
+	for i := 0; closed.Load() == 0; i++ {
+		if i > 100 {
+			t.Fatalf("idle connection of discarded transport kept open")
+		}
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "app.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	s := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Host+r.URL.String())
+	})}
+	go s.Serve(l)
+	defer s.Close()
+
+	c := NewURL("unix://" + socket)
+	c.AddURL("/v1")
+	r := c.NewURL("info?all=1")
+	if body, err := r.Text(); err != nil || body != "localhost/v1/info?all=1" {
+		t.Fatalf("unexpected unix socket response: %s (%v)", body, err)
+	}
+	tcp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "tcp"+r.URL.String())
+	}))
+	defer tcp.Close()
+	r = c.NewURL(tcp.URL + "/info")
+	if body, err := r.Text(); err != nil || body != "tcp/info" {
+		t.Fatalf("unexpected response of host after socket: %s (%v)", body, err)
+	}
+	if r = c.NewURL("info"); r.TransportConfig.UnixSocket != socket {
+		t.Fatalf("socket of base request cleared by clone")
+	}
+
+	r = NewURL("http+unix://" + url.PathEscape(socket) + "/status")
+	if body, err := r.Text(); err != nil || body != "localhost/status" {
+		t.Fatalf("unexpected http+unix response: %s (%v)", body, err)
+	}
+
+	r = NewURL("http://sidecar/health")
+	r.SetDialer(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr != "sidecar:80" {
+			return nil, fmt.Errorf("unexpected address %s", addr)
+		}
+		return new(net.Dialer).DialContext(ctx, "unix", socket)
+	})
+	if body, err := r.Text(); err != nil || body != "sidecar/health" {
+		t.Fatalf("unexpected custom dialer response: %s (%v)", body, err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	s := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Host+" "+r.TLS.ServerName)
+	}))
+	defer s.Close()
+	_, port, _ := net.SplitHostPort(s.Listener.Addr().String())
+
+	c := New()
+	c.AppendRootCA(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw}))
+	c.SetResolve("Example.com:443", "127.0.0.1:"+port)
+	r := c.NewURL("https://example.com/")
+	if body, err := r.Text(); err != nil || body != "example.com example.com" {
+		t.Fatalf("unexpected resolved response: %s (%v)", body, err)
+	}
+
+	c.SetResolve("example.com:443", "")
+	c.SetResolve("example.com", "127.0.0.1")
+	r = c.NewURL("https://example.com:" + port)
+	if body, err := r.Text(); err != nil || body != "example.com:"+port+" example.com" {
+		t.Fatalf("unexpected response resolved by host: %s (%v)", body, err)
+	}
+
+	c.SetResolve("example.com", "")
+	c.SetResolver(func(ctx context.Context, host string) ([]string, error) {
+		if host != "example.com" {
+			return nil, fmt.Errorf("unexpected lookup of %s", host)
+		}
+		return []string{"192.0.2.1", "127.0.0.1"}, nil // first unreachable
+	})
+	c.SetDialTimeout(.5)
+	r = c.NewURL("https://example.com:" + port)
+	if body, err := r.Text(); err != nil || body != "example.com:"+port+" example.com" {
+		t.Fatalf("unexpected response of custom resolver: %s (%v)", body, err)
+	}
+}